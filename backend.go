@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultBackend is used by agents that don't name one.
+const defaultBackend = "claude"
+
+// BackendConfig describes how to run an agent tool. Commands are split
+// on whitespace, and {agent}, {worktree}, {instructions} and {prompt}
+// are replaced in each word.
+type BackendConfig struct {
+	// Command starts an interactive session, e.g. "aider --model haiku".
+	Command string `yaml:"command"`
+	// Run runs one prompt headless. Without a {prompt} word the prompt
+	// is written to the tool's stdin.
+	Run string `yaml:"run,omitempty"`
+	// Process is the program name 'agenter status' looks for. Defaults
+	// to the command's first word.
+	Process string `yaml:"process,omitempty"`
+	// Install tells users where to get the tool when it's missing.
+	Install string `yaml:"install,omitempty"`
+	// Instructions is the file in the worktree the tool reads its
+	// standing instructions from. Setup generates it for each agent.
+	Instructions string `yaml:"instructions,omitempty"`
+
+	title string
+}
+
+// builtinBackends are the tools agenter knows out of the box. A project
+// can override any of them in its backends section.
+var builtinBackends = map[string]BackendConfig{
+	"claude": {
+		Command:      "claude",
+		Run:          "claude -p",
+		Install:      "https://claude.ai/code",
+		Instructions: "CLAUDE.local.md",
+		title:        "Claude Code",
+	},
+	"aider": {
+		Command:      "aider --read {instructions}",
+		Run:          "aider --read {instructions} --yes-always --message {prompt}",
+		Install:      "https://aider.chat",
+		Instructions: "CONVENTIONS.local.md",
+		title:        "Aider",
+	},
+	"codex": {
+		Command:      "codex",
+		Run:          "codex exec {prompt}",
+		Install:      "https://github.com/openai/codex",
+		Instructions: "AGENTS.override.md",
+		title:        "Codex",
+	},
+	"gemini": {
+		Command:      "gemini",
+		Run:          "gemini --prompt {prompt}",
+		Install:      "https://github.com/google-gemini/gemini-cli",
+		Instructions: "GEMINI.md",
+		title:        "Gemini CLI",
+	},
+}
+
+// Backend is a resolved backend for one agent.
+type Backend struct {
+	Name string
+	BackendConfig
+}
+
+// Title is the tool's display name, e.g. "Claude Code".
+func (b *Backend) Title() string {
+	if b.title != "" {
+		return b.title
+	}
+	return b.Name
+}
+
+// binary is the first word of the interactive command.
+func (b *Backend) binary() string {
+	fields := strings.Fields(b.Command)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
+
+// ProcessName is what the tool shows up as in the process list.
+func (b *Backend) ProcessName() string {
+	if b.Process != "" {
+		return b.Process
+	}
+	return filepath.Base(b.binary())
+}
+
+// Path locates the tool's binary. Claude has its own lookup so that
+// AGENTER_CLAUDE and the ~/.claude/local install keep working.
+func (b *Backend) Path() (string, error) {
+	return lookupBinary(b.binary())
+}
+
+// lookupBinary finds the executable a command template starts with.
+func lookupBinary(name string) (string, error) {
+	if name == "claude" {
+		return FindClaudePath()
+	}
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", fmt.Errorf("%s not found", name)
+	}
+	return path, nil
+}
+
+// expandBackendTemplate splits a command template into words and fills
+// in the placeholders. It reports whether {prompt} was used.
+func expandBackendTemplate(template string, vars map[string]string) ([]string, bool) {
+	usedPrompt := strings.Contains(template, "{prompt}")
+	words := strings.Fields(template)
+	for i, word := range words {
+		for key, value := range vars {
+			word = strings.ReplaceAll(word, "{"+key+"}", value)
+		}
+		words[i] = word
+	}
+	return words, usedPrompt
+}
+
+// Backend returns the backend agent runs with. A project's backends
+// section can define new ones or override parts of a built-in one.
+func (c *ProjectConfig) Backend(agent *AgentConfig) (*Backend, error) {
+	name := agent.Backend
+	if name == "" {
+		name = defaultBackend
+	}
+
+	backend, builtin := builtinBackends[name]
+	custom, ok := c.Backends[name]
+	if !builtin && !ok {
+		return nil, fmt.Errorf("agent %s uses unknown backend %q (must be %s or one defined under backends)",
+			agent.Name, name, strings.Join(builtinBackendNames(), ", "))
+	}
+	if ok {
+		if custom.Command != "" {
+			backend.Command = custom.Command
+		}
+		if custom.Run != "" {
+			backend.Run = custom.Run
+		}
+		if custom.Process != "" {
+			backend.Process = custom.Process
+		}
+		if custom.Install != "" {
+			backend.Install = custom.Install
+		}
+		if custom.Instructions != "" {
+			backend.Instructions = custom.Instructions
+		}
+	}
+	return &Backend{Name: name, BackendConfig: backend}, nil
+}
+
+// builtinBackendNames lists the built-in backends alphabetically.
+func builtinBackendNames() []string {
+	names := make([]string, 0, len(builtinBackends))
+	for name := range builtinBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsBackendInstalled checks that a backend's tool can be found.
+func IsBackendInstalled(b *Backend) error {
+	path, err := b.Path()
+	if err != nil {
+		if b.Install != "" {
+			return fmt.Errorf("%s is not installed. Install it from %s", b.Title(), b.Install)
+		}
+		return fmt.Errorf("%s is not installed (%v)", b.Title(), err)
+	}
+	LogDebug("Found %s at: %s", b.Name, path)
+	return nil
+}
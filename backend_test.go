@@ -0,0 +1,130 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProjectConfigResolvesBackends(t *testing.T) {
+	cfg, err := ParseProjectConfig([]byte(`
+backends:
+  claude:
+    command: claude --model sonnet
+  cheap:
+    command: cheap-agent --model haiku
+    run: cheap-agent --task {prompt}
+agents:
+  - name: forge
+  - name: lint
+    backend: cheap
+  - name: pair
+    backend: aider
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		agent   string
+		name    string
+		command string
+		run     string
+		process string
+	}{
+		{"forge", "claude", "claude --model sonnet", "claude -p", "claude"},
+		{"lint", "cheap", "cheap-agent --model haiku", "cheap-agent --task {prompt}", "cheap-agent"},
+		{"pair", "aider", "aider --read {instructions}", "aider --read {instructions} --yes-always --message {prompt}", "aider"},
+	}
+	for _, tt := range tests {
+		backend, err := cfg.Backend(cfg.Agent(tt.agent))
+		if err != nil {
+			t.Errorf("%s: %v", tt.agent, err)
+			continue
+		}
+		if backend.Name != tt.name || backend.Command != tt.command || backend.Run != tt.run || backend.ProcessName() != tt.process {
+			t.Errorf("%s: got %+v", tt.agent, backend)
+		}
+	}
+
+	for _, bad := range []string{
+		"agents:\n  - name: forge\n    backend: nope\n",
+		"backends:\n  empty: {}\nagents:\n  - name: forge\n    backend: empty\n",
+	} {
+		if _, err := ParseProjectConfig([]byte(bad)); err == nil {
+			t.Errorf("expected error for:\n%s", bad)
+		}
+	}
+}
+
+func TestExpandBackendTemplate(t *testing.T) {
+	words, usedPrompt := expandBackendTemplate("tool --dir {worktree} --task {prompt}", map[string]string{
+		"worktree": "/work/app-forge",
+		"prompt":   "fix the build",
+	})
+	if strings.Join(words, "|") != "tool|--dir|/work/app-forge|--task|fix the build" || !usedPrompt {
+		t.Errorf("got %q, %v", words, usedPrompt)
+	}
+
+	if _, usedPrompt := expandBackendTemplate("claude -p", nil); usedPrompt {
+		t.Error("claude -p doesn't take the prompt as an argument")
+	}
+}
+
+func TestRunUsesAgentBackend(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	os.WriteFile(filepath.Join(repo, ProjectConfigFile), []byte(`
+backends:
+  cheap:
+    command: cheap-agent
+    run: cheap-agent --task {prompt} --agent {agent}
+  split:
+    command: split-ui
+    run: split-batch --task {prompt}
+agents:
+  - name: forge
+  - name: lint
+    backend: cheap
+    args: [--model, haiku]
+  - name: review
+    backend: split
+`), 0644)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	binDir := t.TempDir()
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	script := "#!/bin/sh\nfor arg in \"$@\"; do echo \"$arg\"; done > \"$HOME/cheap-args\"\necho \"$AGENTER_AGENT\" >> \"$HOME/cheap-args\"\n"
+	os.WriteFile(filepath.Join(binDir, "cheap-agent"), []byte(script), 0755)
+	os.WriteFile(filepath.Join(binDir, "split-ui"), []byte("#!/bin/sh\necho ui > \"$HOME/split-ran\"\n"), 0755)
+	os.WriteFile(filepath.Join(binDir, "split-batch"), []byte("#!/bin/sh\necho batch \"$@\" > \"$HOME/split-ran\"\n"), 0755)
+
+	os.Chdir(repo)
+	if err := runRunImpl("lint", runOptions{Prompt: "tidy up imports"}); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(os.Getenv("HOME"), "cheap-args"))
+	want := "--task\ntidy up imports\n--agent\nlint\n--model\nhaiku\nlint\n"
+	if string(data) != want {
+		t.Errorf("cheap-agent got:\n%s\nwant:\n%s", data, want)
+	}
+
+	// The headless command's own binary runs, not the interactive one
+	if err := runRunImpl("review", runOptions{Prompt: "hi"}); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(os.Getenv("HOME"), "split-ran")); string(data) != "batch --task hi\n" {
+		t.Errorf("split backend ran %q", data)
+	}
+
+	status := collectAgentStatus(CurrentConfig(), CurrentConfig().Agent("lint"))
+	if status.Tool != "cheap-agent" {
+		t.Errorf("status looks for %q", status.Tool)
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// defaultBranchTemplate names topic branches <base branch>-<topic>.
+const defaultBranchTemplate = "{branch}-{topic}"
+
+// branchTemplateFields are the placeholders a branch template may use.
+var branchTemplateFields = map[string]bool{
+	"agent":  true, // the agent's name
+	"branch": true, // the agent's base branch
+	"user":   true, // git user.name as a slug, or $USER
+	"issue":  true, // from --issue
+	"topic":  true,
+}
+
+var branchTemplateField = regexp.MustCompile(`\{([^{}]*)\}`)
+
+// checkRefComponent reports why s can't be one slash-separated part of
+// a branch name, following git check-ref-format.
+func checkRefComponent(s string) error {
+	switch {
+	case s == "":
+		return fmt.Errorf("is empty")
+	case strings.HasPrefix(s, "."):
+		return fmt.Errorf("can't start with '.'")
+	case strings.HasSuffix(s, "."), strings.HasSuffix(s, ".lock"):
+		return fmt.Errorf("can't end with '.' or '.lock'")
+	case strings.Contains(s, ".."):
+		return fmt.Errorf("can't contain '..'")
+	case strings.Contains(s, "@{"):
+		return fmt.Errorf("can't contain '@{'")
+	case s == "@":
+		return fmt.Errorf("can't be '@'")
+	}
+	for _, r := range s {
+		if r < 0x20 || r == 0x7f {
+			return fmt.Errorf("can't contain control characters")
+		}
+		if strings.ContainsRune(" ~^:?*[\\", r) {
+			return fmt.Errorf("can't contain %q", r)
+		}
+	}
+	return nil
+}
+
+// checkBranchName reports why name isn't a valid branch name.
+func checkBranchName(name string) error {
+	if strings.HasPrefix(name, "-") {
+		return fmt.Errorf("can't start with '-'")
+	}
+	for _, part := range strings.Split(name, "/") {
+		if err := checkRefComponent(part); err != nil {
+			if part == "" {
+				return fmt.Errorf("can't have empty parts between slashes")
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// validateTopic checks that topic can go in a branch name. Topics are a
+// single part; any prefix comes from the branch template.
+func validateTopic(topic string) error {
+	if strings.Contains(topic, "/") {
+		return fmt.Errorf("topic %q can't contain '/' (set branch_template in %s for a prefix)", topic, ProjectConfigFile)
+	}
+	if strings.HasPrefix(topic, "-") {
+		return fmt.Errorf("topic %q can't start with '-'", topic)
+	}
+	if err := checkRefComponent(topic); err != nil {
+		return fmt.Errorf("topic %q %v", topic, err)
+	}
+	return nil
+}
+
+// slugifyTopic turns free text like "Fix flaky tests" into a valid
+// topic like "fix-flaky-tests".
+func slugifyTopic(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_':
+			b.WriteRune(r)
+		case r == '.' && !strings.HasSuffix(b.String(), "."):
+			b.WriteRune(r)
+		case !strings.HasSuffix(b.String(), "-"):
+			b.WriteRune('-')
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), ".lock")
+	return strings.Trim(slug, "-.")
+}
+
+// validateBranchTemplate checks that template only uses known
+// placeholders and gives each agent valid branches of its own.
+func validateBranchTemplate(template string) error {
+	for _, match := range branchTemplateField.FindAllStringSubmatch(template, -1) {
+		if !branchTemplateFields[match[1]] {
+			return fmt.Errorf("branch_template %q has unknown placeholder {%s}", template, match[1])
+		}
+	}
+	if !strings.Contains(template, "{topic}") {
+		return fmt.Errorf("branch_template %q needs {topic}", template)
+	}
+	if !strings.Contains(template, "{agent}") && !strings.Contains(template, "{branch}") {
+		return fmt.Errorf("branch_template %q needs {agent} or {branch} so agents' topics don't collide", template)
+	}
+	sample := expandBranchTemplate(template, map[string]string{
+		"agent": "forge", "branch": "forge-worktree", "user": "user", "issue": "1", "topic": "topic",
+	})
+	if err := checkBranchName(sample); err != nil {
+		return fmt.Errorf("branch_template %q makes invalid branches like %s: %v", template, sample, err)
+	}
+	return nil
+}
+
+// checkBranchTemplateRoster makes sure no agent's topic branches clash
+// with any agent's base branch. git can't have both refs/heads/x and
+// refs/heads/x/y, so a topic can't nest under a base branch or be a
+// directory of one.
+func (c *ProjectConfig) checkBranchTemplateRoster() error {
+	// \x00 stands in for the parts that vary, since it can't be in a
+	// real branch name
+	const varies = "\x00"
+	for i := range c.Agents {
+		agent := &c.Agents[i]
+		topic := strings.Split(expandBranchTemplate(c.branchTemplate(), map[string]string{
+			"agent": agent.Name, "branch": agent.Branch, "user": varies, "issue": varies, "topic": varies,
+		}), "/")
+		fixed := 0
+		for fixed < len(topic) && !strings.Contains(topic[fixed], varies) {
+			fixed++
+		}
+
+		for _, other := range c.Agents {
+			base := strings.Split(other.Branch, "/")
+			switch {
+			case len(base) <= fixed && len(base) < len(topic) && sameParts(base, topic[:len(base)]):
+				return fmt.Errorf("branch_template %q puts %s's topics under %s's base branch %s",
+					c.branchTemplate(), agent.Name, other.Name, other.Branch)
+			case len(base) > len(topic) && sameParts(topic[:fixed], base[:fixed]):
+				return fmt.Errorf("branch_template %q lets %s's topics clash with %s's base branch %s",
+					c.branchTemplate(), agent.Name, other.Name, other.Branch)
+			}
+		}
+	}
+	return nil
+}
+
+// sameParts reports whether two branch name parts lists are equal.
+func sameParts(a []string, b []string) bool {
+	return strings.Join(a, "/") == strings.Join(b, "/")
+}
+
+// expandBranchTemplate fills in template's placeholders from values.
+func expandBranchTemplate(template string, values map[string]string) string {
+	return branchTemplateField.ReplaceAllStringFunc(template, func(field string) string {
+		return values[strings.Trim(field, "{}")]
+	})
+}
+
+// branchUser is the {user} in branch names: git's user.name as a slug,
+// or $USER.
+func branchUser(dir string) string {
+	if name, err := gitOutput(dir, "config", "user.name"); err == nil {
+		if slug := slugifyTopic(name); slug != "" {
+			return slug
+		}
+	}
+	return slugifyTopic(os.Getenv("USER"))
+}
+
+// branchTemplate returns the project's template for topic branches.
+func (c *ProjectConfig) branchTemplate() string {
+	if c.BranchTemplate == "" {
+		return defaultBranchTemplate
+	}
+	return c.BranchTemplate
+}
+
+// branchValues returns what agent's placeholders expand to, except
+// topic and issue.
+func (c *ProjectConfig) branchValues(agent *AgentConfig) map[string]string {
+	values := map[string]string{"agent": agent.Name, "branch": agent.Branch}
+	if strings.Contains(c.branchTemplate(), "{user}") {
+		values["user"] = branchUser(c.Root)
+	}
+	return values
+}
+
+// TopicBranch returns agent's branch for topic. issue is only needed
+// when the template has {issue}.
+func (c *ProjectConfig) TopicBranch(agent *AgentConfig, topic string, issue string) (string, error) {
+	if err := validateTopic(topic); err != nil {
+		return "", err
+	}
+	template := c.branchTemplate()
+	values := c.branchValues(agent)
+	if strings.Contains(template, "{user}") && values["user"] == "" {
+		return "", fmt.Errorf("branch_template %q needs {user}; set git's user.name", template)
+	}
+	if strings.Contains(template, "{issue}") {
+		if issue == "" {
+			return "", fmt.Errorf("branch_template %q needs an issue; pass --issue", template)
+		}
+		if err := checkRefComponent(issue); err != nil || strings.Contains(issue, "/") {
+			return "", fmt.Errorf("invalid issue %q", issue)
+		}
+	}
+	values["topic"] = topic
+	values["issue"] = issue
+
+	branch := expandBranchTemplate(template, values)
+	if err := checkBranchName(branch); err != nil {
+		return "", fmt.Errorf("invalid branch name %s: %v", branch, err)
+	}
+	return branch, nil
+}
+
+// TopicGlob returns a for-each-ref pattern, relative to refs/heads,
+// that matches at least all of agent's topic branches.
+func (c *ProjectConfig) TopicGlob(agent *AgentConfig) string {
+	values := c.branchValues(agent)
+	values["topic"], values["issue"] = "*", "*"
+	if values["user"] == "" {
+		values["user"] = "*"
+	}
+	return expandBranchTemplate(c.branchTemplate(), values)
+}
+
+// TopicOf returns the topic of branch if it's one of agent's topic
+// branches.
+func (c *ProjectConfig) TopicOf(agent *AgentConfig, branch string) (string, bool) {
+	values := c.branchValues(agent)
+	pattern := regexp.QuoteMeta(c.branchTemplate())
+	// QuoteMeta escapes the braces, so match the escaped placeholders
+	pattern = regexp.MustCompile(`\\\{([a-z]+)\\\}`).ReplaceAllStringFunc(pattern, func(field string) string {
+		name := strings.Trim(field, `\{}`)
+		switch {
+		case name == "topic":
+			return `(?P<topic>[^/]+)`
+		case name == "issue", name == "user" && values["user"] == "":
+			return `[^/]+`
+		}
+		return regexp.QuoteMeta(values[name])
+	})
+	re, err := regexp.Compile("^" + pattern + "$")
+	if err != nil {
+		return "", false
+	}
+	match := re.FindStringSubmatch(branch)
+	if match == nil {
+		return "", false
+	}
+	return match[re.SubexpIndex("topic")], true
+}
+
+// topicBranches lists agent's local topic branches.
+func topicBranches(cfg *ProjectConfig, agent *AgentConfig) []string {
+	output, err := gitOutput(cfg.Root, "for-each-ref", "--format=%(refname:short)", "refs/heads/"+cfg.TopicGlob(agent))
+	if err != nil || output == "" {
+		return nil
+	}
+	var branches []string
+	for _, branch := range strings.Split(output, "\n") {
+		if _, ok := cfg.TopicOf(agent, branch); ok {
+			branches = append(branches, branch)
+		}
+	}
+	return branches
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestValidateTopic(t *testing.T) {
+	tests := []struct {
+		topic string
+		slug  string
+		valid bool
+	}{
+		{"fix-login", "fix-login", true},
+		{"v1.2_docs", "v1.2_docs", true},
+		{"fix flaky tests", "fix-flaky-tests", false},
+		{"feat/x", "feat-x", false},
+		{"Add  OAuth: Google!", "add-oauth-google", false},
+		{"..hidden..", "hidden", false},
+		{"-flag", "flag", false},
+		{"wip.lock", "wip", false},
+		{"a@{b}", "a-b", false},
+		{"", "", false},
+		{"???", "", false},
+	}
+
+	for _, tt := range tests {
+		err := validateTopic(tt.topic)
+		if (err == nil) != tt.valid {
+			t.Errorf("validateTopic(%q) = %v, want valid %v", tt.topic, err, tt.valid)
+		}
+		slug := slugifyTopic(tt.topic)
+		if slug != tt.slug {
+			t.Errorf("slugifyTopic(%q) = %q, want %q", tt.topic, slug, tt.slug)
+		}
+		if slug != "" {
+			if err := validateTopic(slug); err != nil {
+				t.Errorf("slug %q is invalid: %v", slug, err)
+			}
+		}
+	}
+}
+
+func TestValidateBranchTemplate(t *testing.T) {
+	tests := []struct {
+		template string
+		err      string
+	}{
+		{"{branch}-{topic}", ""},
+		{"{agent}/{topic}", ""},
+		{"{user}/{agent}/{issue}-{topic}", ""},
+		{"{agent}/{ticket}-{topic}", "unknown placeholder {ticket}"},
+		{"{agent}", "needs {topic}"},
+		{"feature/{topic}", "needs {agent} or {branch}"},
+		{"{agent}//{topic}", "empty parts"},
+		{"{agent} {topic}", "can't contain ' '"},
+	}
+
+	for _, tt := range tests {
+		err := validateBranchTemplate(tt.template)
+		if tt.err == "" && err != nil {
+			t.Errorf("%q: unexpected error %v", tt.template, err)
+		}
+		if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+			t.Errorf("%q: error %v, want %q", tt.template, err, tt.err)
+		}
+	}
+}
+
+func TestBranchTemplateFitsRoster(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{"default", "agents: [{name: forge}, {name: bot, branch: bot}]", ""},
+		{"agent dirs", "branch_template: \"{agent}/{topic}\"\nagents: [{name: forge}, {name: bot}]", ""},
+		{"under own base", "branch_template: \"{agent}/{topic}\"\nagents: [{name: forge}, {name: bot, branch: bot}]", "under bot's base branch bot"},
+		{"under base branch", "branch_template: \"{branch}/{topic}\"\nagents: [{name: forge}]", "under forge's base branch forge-worktree"},
+		{"under another base", "branch_template: \"{user}/{agent}/{topic}\"\nagents: [{name: forge}]", ""},
+		{"prefix of a base", "branch_template: \"{agent}/{topic}\"\nagents: [{name: forge}, {name: bot, branch: forge/base/x}]", "clash with bot's base branch forge/base/x"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseProjectConfig([]byte(tt.yaml))
+			if tt.err == "" && err != nil {
+				t.Errorf("unexpected error %v", err)
+			}
+			if tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)) {
+				t.Errorf("error %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestTopicBranchTemplates(t *testing.T) {
+	repo := newTestRepo(t)
+	runTestGit(t, repo, "config", "user.name", "Ada Lovelace")
+	agent := &AgentConfig{Name: "forge", Branch: "forge-worktree"}
+
+	tests := []struct {
+		template string
+		issue    string
+		branch   string
+		glob     string
+		other    string // a branch that isn't forge's topic
+	}{
+		{"", "", "forge-worktree-fix", "forge-worktree-*", "axiom-worktree-fix"},
+		{"{agent}/{topic}", "", "forge/fix", "forge/*", "forge/a/b"},
+		{"{user}/{agent}/{issue}-{topic}", "42", "ada-lovelace/forge/42-fix", "ada-lovelace/forge/*-*", "bob/forge/42-fix"},
+	}
+
+	for _, tt := range tests {
+		cfg := &ProjectConfig{BranchTemplate: tt.template, Root: repo}
+		branch, err := cfg.TopicBranch(agent, "fix", tt.issue)
+		if err != nil {
+			t.Errorf("%q: %v", tt.template, err)
+			continue
+		}
+		if branch != tt.branch {
+			t.Errorf("%q: branch = %s, want %s", tt.template, branch, tt.branch)
+		}
+		if glob := cfg.TopicGlob(agent); glob != tt.glob {
+			t.Errorf("%q: glob = %s, want %s", tt.template, glob, tt.glob)
+		}
+		if topic, ok := cfg.TopicOf(agent, branch); !ok || topic != "fix" {
+			t.Errorf("%q: TopicOf(%s) = %q, %v", tt.template, branch, topic, ok)
+		}
+		if _, ok := cfg.TopicOf(agent, tt.other); ok {
+			t.Errorf("%q: %s taken for forge's topic", tt.template, tt.other)
+		}
+		if _, ok := cfg.TopicOf(agent, agent.Branch); ok {
+			t.Errorf("%q: base branch taken for a topic", tt.template)
+		}
+	}
+
+	cfg := &ProjectConfig{BranchTemplate: "{agent}/{issue}-{topic}", Root: repo}
+	if _, err := cfg.TopicBranch(agent, "fix", ""); err == nil || !strings.Contains(err.Error(), "--issue") {
+		t.Errorf("missing issue: %v", err)
+	}
+}
+
+func TestWorktreeMakeUsesTemplate(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	os.WriteFile(repo+"/"+ProjectConfigFile, []byte("branch_template: \"{agent}/{topic}\"\nagents:\n  - name: forge\n"), 0644)
+	runTestGit(t, repo, "add", ProjectConfigFile)
+	runTestGit(t, repo, "commit", "-q", "-m", "config")
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	forge := CurrentConfig().WorktreePath("forge")
+	os.Chdir(forge)
+
+	// Declining the slug leaves the branch alone
+	stdin := os.Stdin
+	defer func() { os.Stdin = stdin }()
+	os.Stdin, _ = os.Open(os.DevNull)
+	err := runWorktreeMakeImpl("fix flaky tests", "")
+	if err == nil || !strings.Contains(err.Error(), `try "fix-flaky-tests"`) {
+		t.Fatalf("expected invalid topic error, got %v", err)
+	}
+
+	answer, _ := os.CreateTemp(t.TempDir(), "answer")
+	answer.WriteString("y\n")
+	answer.Seek(0, 0)
+	os.Stdin = answer
+	if err := runWorktreeMakeImpl("fix flaky tests", ""); err != nil {
+		t.Fatal(err)
+	}
+	if branch := strings.TrimSpace(runTestGit(t, forge, "rev-parse", "--abbrev-ref", "HEAD")); branch != "forge/fix-flaky-tests" {
+		t.Errorf("branch = %s", branch)
+	}
+
+	status := collectAgentStatus(CurrentConfig(), CurrentConfig().Agent("forge"))
+	for _, problem := range status.Problems {
+		t.Errorf("status problem on a templated topic: %s", problem)
+	}
+}
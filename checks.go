@@ -23,8 +23,17 @@ func IsGitHubCLIAuthenticated() error {
 	return nil
 }
 
-// FindClaudePath locates the claude binary. First tries PATH, then common installation location.
+// FindClaudePath locates the claude binary. AGENTER_CLAUDE wins if set,
+// then PATH, then the common installation location.
 func FindClaudePath() (string, error) {
+	if override := os.Getenv("AGENTER_CLAUDE"); override != "" {
+		path, err := exec.LookPath(override)
+		if err != nil {
+			return "", fmt.Errorf("AGENTER_CLAUDE=%s: %v", override, err)
+		}
+		return path, nil
+	}
+
 	// First try PATH
 	if path, err := exec.LookPath("claude"); err == nil {
 		return path, nil
@@ -78,16 +87,14 @@ func HasGitRepository(path string) bool {
 	return info.IsDir() || info.Mode().IsRegular()
 }
 
-// Checks if agent name is valid (forge, axiom, or jarvis).
-// We use fixed names to keep workspaces separate.
+// Checks if agent name is in the project's roster.
+// Names come from agenter.yaml, or forge, axiom and jarvis by default.
 func IsKnownAgentName(agent string) error {
-	validAgents := []string{"forge", "axiom", "jarvis"}
-	for _, valid := range validAgents {
-		if agent == valid {
-			return nil
-		}
+	cfg := CurrentConfig()
+	if cfg.Agent(agent) != nil {
+		return nil
 	}
-	return fmt.Errorf("unknown agent name: %s (must be forge, axiom, or jarvis)", agent)
+	return fmt.Errorf("unknown agent name: %s (must be %s)", agent, describeAgentNames(cfg.AgentNames()))
 }
 
 // Checks if agent is in correct directory by checking suffix.
@@ -99,12 +106,28 @@ func IsInAgentWorkspace(agent string) error {
 		return fmt.Errorf("failed to get current directory: %v", err)
 	}
 
-	dir := filepath.Base(cwd)
+	return IsAgentWorkspaceDir(cwd, agent)
+}
+
+// Same check as IsInAgentWorkspace for a directory we're about to
+// start the agent in, which need not be the current one.
+func IsAgentWorkspaceDir(path string, agent string) error {
+	dir := filepath.Base(path)
 	expectedSuffix := fmt.Sprintf("-%s", agent)
 
 	if !strings.HasSuffix(dir, expectedSuffix) {
 		return fmt.Errorf("%s can only run in directories ending with '%s'", agent, expectedSuffix)
 	}
 
+	// With names like bot and my-bot, project-my-bot ends in -bot too but
+	// belongs to my-bot
+	if match := CurrentConfig().AgentForDir(path); match == nil || match.Name != agent {
+		owner := "another agent"
+		if match != nil {
+			owner = match.Name
+		}
+		return fmt.Errorf("%s belongs to %s, not %s", dir, owner, agent)
+	}
+
 	return nil
 }
@@ -12,13 +12,26 @@ import (
 func runCheckImpl() error {
 	PrintHeader("Checking Tools")
 
-	// Check Claude Code
-	PrintStep(1, 4, "Checking Claude Code installation...")
-	if err := IsClaudeInstalled(); err != nil {
-		PrintError("Claude Code not found: %v", err)
-		return err
+	// Check the tools the roster actually uses
+	PrintStep(1, 4, "Checking agent tools...")
+	cfg := CurrentConfig()
+	checked := make(map[string]bool)
+	for i := range cfg.Agents {
+		backend, err := cfg.Backend(&cfg.Agents[i])
+		if err != nil {
+			PrintError("%v", err)
+			return err
+		}
+		if checked[backend.Name] {
+			continue
+		}
+		checked[backend.Name] = true
+		if err := IsBackendInstalled(backend); err != nil {
+			PrintError("%s not found: %v", backend.Title(), err)
+			return err
+		}
+		PrintSuccess("%s is installed", backend.Title())
 	}
-	PrintSuccess("Claude Code is installed")
 
 	// Check Git
 	PrintStep(2, 4, "Checking Git installation...")
@@ -113,75 +126,67 @@ func runSetupImpl(repoPath string) error {
 		return fmt.Errorf("%s is not a git repository", absPath)
 	}
 
-	PrintHeader(fmt.Sprintf("Setting up %s for multi-agent development", filepath.Base(absPath)))
+	// Load the roster for the repository being set up, which may not
+	// be the one we're standing in
+	cfg, err := LoadProjectConfig(absPath)
+	if err != nil {
+		return err
+	}
+	activeConfig = cfg
 
-	// Get the repository name
-	repoName := filepath.Base(absPath)
-	parentDir := filepath.Dir(absPath)
+	PrintHeader(fmt.Sprintf("Setting up %s for multi-agent development", filepath.Base(absPath)))
 
 	// Create worktrees for each agent
-	agents := []string{"forge", "axiom", "jarvis"}
-	for i, agent := range agents {
-		PrintStep(i+1, len(agents), fmt.Sprintf("Creating %s worktree...", agent))
+	var created []int
+	for i, agent := range cfg.Agents {
+		PrintStep(i+1, len(cfg.Agents), fmt.Sprintf("Creating %s worktree...", agent.Name))
 
-		worktreePath := filepath.Join(parentDir, fmt.Sprintf("%s-%s", repoName, agent))
-		branchName := fmt.Sprintf("%s-worktree", agent)
+		worktreePath := cfg.WorktreePath(agent.Name)
+		branchName := agent.Branch
 
 		// Check if worktree already exists
 		if _, err := os.Stat(worktreePath); !os.IsNotExist(err) {
 			PrintWarning("Worktree %s already exists", worktreePath)
-			continue
+		} else {
+			// Create worktree
+			cmd := exec.Command("git", "-C", absPath, "worktree", "add", "-b", branchName, worktreePath)
+			if output, err := cmd.CombinedOutput(); err != nil {
+				PrintError("Could not create worktree: %s", string(output))
+				return err
+			}
+
+			PrintSuccess("Created %s", FormatPath(worktreePath))
+			created = append(created, i)
 		}
 
-		// Create worktree
-		cmd := exec.Command("git", "-C", absPath, "worktree", "add", "-b", branchName, worktreePath)
-		if output, err := cmd.CombinedOutput(); err != nil {
-			PrintError("Could not create worktree: %s", string(output))
-			return err
+		// Tell the agent who it is, refreshed on every setup
+		if path, err := writeAgentInstructions(cfg, &cfg.Agents[i], worktreePath); err != nil {
+			PrintWarning("Could not write instructions for %s: %v", agent.Name, err)
+		} else if path != "" {
+			LogDebug("Wrote %s", path)
 		}
-
-		PrintSuccess("Created %s", FormatPath(worktreePath))
 	}
 
-	// Print launch instructions
-	fmt.Println()
-	PrintBold("Ready! Launch agents with:")
-	for _, agent := range agents {
-		worktreePath := filepath.Join(parentDir, fmt.Sprintf("%s-%s", repoName, agent))
-		fmt.Printf("  cd %s && agenter launch %s\n", FormatPath(worktreePath), agent)
+	// Only new worktrees need setting up, e.g. installing dependencies
+	for _, i := range created {
+		agent := &cfg.Agents[i]
+		if err := runHooks("post_setup", agent, cfg.WorktreePath(agent.Name)); err != nil {
+			PrintWarning("%v", err)
+		}
 	}
 
-	return nil
-}
-
-// runLaunchImpl runs the launch command
-func runLaunchImpl(agent string) error {
-	// Validate agent name
-	if err := IsKnownAgentName(agent); err != nil {
-		return err
+	// Remember the project for 'agenter list'
+	if err := RegisterProject(cfg); err != nil {
+		PrintWarning("Could not record project in %s: %v", FormatPath(globalConfigPath()), err)
 	}
 
-	// Validate directory
-	if err := IsInAgentWorkspace(agent); err != nil {
-		return err
+	// Print launch instructions
+	fmt.Println()
+	PrintBold("Ready! Launch agents from anywhere in %s with:", cfg.Name)
+	for _, agent := range cfg.Agents {
+		fmt.Printf("  agenter launch %s\n", agent.Name)
 	}
+	PrintInfo("From outside the project, add --project %s", cfg.Name)
 
-	// Set environment variable
-	os.Setenv("WHO_AM_I", agent)
-
-	PrintSuccess("Launching Claude as %s...", PrintAgent(agent))
-
-	// Find claude binary
-	claudePath, err := FindClaudePath()
-	if err != nil {
-		return fmt.Errorf("claude not found: %v", err)
-	}
-	
-	// Launch Claude Code
-	cmd := exec.Command(claudePath)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-
-	return cmd.Run()
+	return nil
 }
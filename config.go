@@ -0,0 +1,363 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectConfigFile is the per-repository config committed alongside the code.
+const ProjectConfigFile = "agenter.yaml"
+
+// ProjectConfig describes the agents that work on one repository.
+type ProjectConfig struct {
+	Name    string        `yaml:"name,omitempty"`
+	Agents  []AgentConfig `yaml:"agents"`
+	Sandbox SandboxConfig `yaml:"sandbox,omitempty"`
+	// Backends defines custom tools, or overrides built-in ones, by name.
+	Backends map[string]BackendConfig `yaml:"backends,omitempty"`
+	// Rules are project conventions listed in every agent's instructions.
+	Rules []string `yaml:"rules,omitempty"`
+	// InstructionsTemplate replaces the built-in instructions template.
+	// Relative paths are resolved against the main repository.
+	InstructionsTemplate string `yaml:"instructions_template,omitempty"`
+	// Hooks are shell commands run at points in the workflow, keyed by
+	// event name such as pre_push.
+	Hooks map[string]hookCommands `yaml:"hooks,omitempty"`
+	// Limits bound every agent's sessions unless the agent overrides them.
+	Limits LimitsConfig `yaml:"limits,omitempty"`
+	// MainBranch is the integration branch. Detected from origin/HEAD
+	// when empty.
+	MainBranch string `yaml:"main_branch,omitempty"`
+	// UpdateStrategy is how 'worktree next' updates the base branch from
+	// the integration branch: merge (the default), rebase or reset.
+	UpdateStrategy string `yaml:"update_strategy,omitempty"`
+	// BranchTemplate names topic branches, e.g. "{agent}/{topic}".
+	// Defaults to "{branch}-{topic}".
+	BranchTemplate string `yaml:"branch_template,omitempty"`
+	// Providers maps self-hosted git hosts to github, gitlab, bitbucket
+	// or gitea, for pull request links.
+	Providers map[string]string `yaml:"providers,omitempty"`
+	// PullRequest shapes pull requests opened by 'worktree push --pr'.
+	PullRequest PullRequestConfig `yaml:"pull_request,omitempty"`
+
+	// Root is the main repository the config belongs to. Empty when
+	// we're not inside a repository.
+	Root string `yaml:"-"`
+	// Source is the file the config was read from. Empty for defaults.
+	Source string `yaml:"-"`
+}
+
+// SandboxConfig adjusts what --sandbox lets agents touch. Relative paths
+// are resolved against the main repository.
+type SandboxConfig struct {
+	// Writable paths stay writable even inside the read-only parent dir.
+	Writable []string `yaml:"writable,omitempty"`
+	// Hide paths are replaced with an empty file or directory.
+	Hide []string `yaml:"hide,omitempty"`
+}
+
+// AgentConfig is one entry in the agent roster.
+type AgentConfig struct {
+	Name   string `yaml:"name"`
+	Color  string `yaml:"color,omitempty"`
+	Role   string `yaml:"role,omitempty"`
+	Branch string `yaml:"branch,omitempty"`
+	// Backend is the tool the agent runs, "claude" unless set.
+	Backend string `yaml:"backend,omitempty"`
+
+	// Args are passed to the tool before any given on the command line.
+	Args []string `yaml:"args,omitempty"`
+	// Env is added to the tool's environment. Values may refer to other
+	// variables as $VAR or ${VAR}.
+	Env map[string]string `yaml:"env,omitempty"`
+	// EnvFile is a .env file merged in underneath Env. Relative paths
+	// are resolved against the agent's worktree.
+	EnvFile string `yaml:"env_file,omitempty"`
+	// Limits override the project's limits for this agent.
+	Limits LimitsConfig `yaml:"limits,omitempty"`
+}
+
+var agentNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+var agentColors = map[string]color.Attribute{
+	"red":     color.FgRed,
+	"green":   color.FgGreen,
+	"yellow":  color.FgYellow,
+	"blue":    color.FgBlue,
+	"magenta": color.FgMagenta,
+	"cyan":    color.FgCyan,
+	"white":   color.FgWhite,
+}
+
+// activeConfig is the roster for the current command. Commands that
+// run before InitConfig (and tests) see the default roster.
+var activeConfig *ProjectConfig
+
+// DefaultProjectConfig returns the original three-agent roster used when
+// a project has no config file.
+func DefaultProjectConfig() *ProjectConfig {
+	cfg := &ProjectConfig{
+		Agents: []AgentConfig{
+			{Name: "forge", Color: "red"},
+			{Name: "axiom", Color: "blue"},
+			{Name: "jarvis", Color: "green"},
+		},
+	}
+	cfg.applyDefaults()
+	return cfg
+}
+
+// CurrentConfig returns the project config loaded for this command.
+func CurrentConfig() *ProjectConfig {
+	if activeConfig == nil {
+		activeConfig = DefaultProjectConfig()
+	}
+	return activeConfig
+}
+
+// InitConfig loads the project config for the repository containing dir.
+// Outside a repository the default roster is used.
+func InitConfig(dir string) error {
+	root, err := findMainRepo(dir)
+	if err != nil {
+		LogDebug("No repository at %s, using default agents", dir)
+		activeConfig = DefaultProjectConfig()
+		return nil
+	}
+
+	cfg, err := LoadProjectConfig(root)
+	if err != nil {
+		return err
+	}
+	activeConfig = cfg
+	return nil
+}
+
+// LoadProjectConfig reads the config for the repository at root.
+// A personal ~/.agenter/projects/<name>.yaml wins over the agenter.yaml
+// committed in the repository, so one person can try out a roster
+// without changing it for the whole team.
+func LoadProjectConfig(root string) (*ProjectConfig, error) {
+	for _, path := range projectConfigPaths(root) {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %v", path, err)
+		}
+
+		cfg, err := ParseProjectConfig(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", FormatPath(path), err)
+		}
+		cfg.Root = root
+		cfg.Source = path
+		if cfg.Name == "" {
+			cfg.Name = filepath.Base(root)
+		}
+		LogDebug("Loaded project config from %s", path)
+		return cfg, nil
+	}
+
+	cfg := DefaultProjectConfig()
+	cfg.Root = root
+	cfg.Name = filepath.Base(root)
+	return cfg, nil
+}
+
+// projectConfigPaths lists candidate config files in priority order.
+func projectConfigPaths(root string) []string {
+	return []string{
+		filepath.Join(agenterDir(), "projects", filepath.Base(root)+".yaml"),
+		filepath.Join(root, ProjectConfigFile),
+	}
+}
+
+// agenterDir is where agenter keeps its own state.
+func agenterDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".agenter")
+}
+
+// ParseProjectConfig decodes and validates a project config.
+// Unknown keys are rejected so typos don't silently fall back to defaults.
+func ParseProjectConfig(data []byte) (*ProjectConfig, error) {
+	cfg := &ProjectConfig{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil {
+		return nil, fmt.Errorf("invalid config: %v", err)
+	}
+
+	cfg.applyDefaults()
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// applyDefaults fills in fields that can be derived from the agent name.
+func (c *ProjectConfig) applyDefaults() {
+	for i := range c.Agents {
+		agent := &c.Agents[i]
+		if agent.Branch == "" {
+			agent.Branch = fmt.Sprintf("%s-worktree", agent.Name)
+		}
+	}
+}
+
+// Validate checks the roster for names and colors we can't work with.
+func (c *ProjectConfig) Validate() error {
+	if len(c.Agents) == 0 {
+		return fmt.Errorf("no agents configured")
+	}
+	// The name is part of paths under ~/.agenter
+	if c.Name != "" && (c.Name == "." || c.Name == ".." || strings.ContainsAny(c.Name, "/\\\x00") ||
+		strings.TrimSpace(c.Name) != c.Name) {
+		return fmt.Errorf("invalid project name %q", c.Name)
+	}
+
+	if err := validateHooks(c.Hooks); err != nil {
+		return err
+	}
+	if c.UpdateStrategy != "" {
+		if err := validateUpdateStrategy(c.UpdateStrategy); err != nil {
+			return err
+		}
+	}
+
+	if c.BranchTemplate != "" {
+		if err := validateBranchTemplate(c.BranchTemplate); err != nil {
+			return err
+		}
+	}
+	if err := c.checkBranchTemplateRoster(); err != nil {
+		return err
+	}
+
+	if err := validateProviders(c.Providers); err != nil {
+		return err
+	}
+	if err := c.PullRequest.validate(); err != nil {
+		return err
+	}
+
+	for name, backend := range c.Backends {
+		if _, builtin := builtinBackends[name]; !builtin && strings.TrimSpace(backend.Command) == "" {
+			return fmt.Errorf("backend %s needs a command", name)
+		}
+	}
+
+	seenNames := make(map[string]bool)
+	seenBranches := make(map[string]bool)
+	for _, agent := range c.Agents {
+		if !agentNamePattern.MatchString(agent.Name) {
+			return fmt.Errorf("invalid agent name %q (use lowercase letters, digits and dashes)", agent.Name)
+		}
+		if seenNames[agent.Name] {
+			return fmt.Errorf("agent %s is listed twice", agent.Name)
+		}
+		seenNames[agent.Name] = true
+
+		if seenBranches[agent.Branch] {
+			return fmt.Errorf("branch %s is used by more than one agent", agent.Branch)
+		}
+		seenBranches[agent.Branch] = true
+
+		if _, ok := agentColors[agent.Color]; agent.Color != "" && !ok {
+			return fmt.Errorf("agent %s has unknown color %q", agent.Name, agent.Color)
+		}
+		if _, err := c.Backend(&agent); err != nil {
+			return err
+		}
+		if _, err := c.LimitsFor(&agent); err != nil {
+			return fmt.Errorf("agent %s: %v", agent.Name, err)
+		}
+		for key := range agent.Env {
+			if key == "" || strings.ContainsAny(key, "= \t") {
+				return fmt.Errorf("agent %s has invalid env variable name %q", agent.Name, key)
+			}
+		}
+	}
+	return nil
+}
+
+// Agent returns the roster entry for name, or nil.
+func (c *ProjectConfig) Agent(name string) *AgentConfig {
+	for i := range c.Agents {
+		if c.Agents[i].Name == name {
+			return &c.Agents[i]
+		}
+	}
+	return nil
+}
+
+// Environ returns the agent's extra environment as sorted KEY=value
+// pairs, with variable references expanded.
+func (a *AgentConfig) Environ() []string {
+	keys := make([]string, 0, len(a.Env))
+	for key := range a.Env {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	env := make([]string, len(keys))
+	for i, key := range keys {
+		env[i] = key + "=" + os.ExpandEnv(a.Env[key])
+	}
+	return env
+}
+
+// AgentNames returns the agent names in roster order.
+func (c *ProjectConfig) AgentNames() []string {
+	names := make([]string, len(c.Agents))
+	for i, agent := range c.Agents {
+		names[i] = agent.Name
+	}
+	return names
+}
+
+// AgentForDir returns the agent whose worktree suffix matches dir's name.
+// The longest name wins so "x-my-bot" belongs to "my-bot", not "bot".
+func (c *ProjectConfig) AgentForDir(dir string) *AgentConfig {
+	base := filepath.Base(dir)
+	var match *AgentConfig
+	for i := range c.Agents {
+		agent := &c.Agents[i]
+		if !strings.HasSuffix(base, "-"+agent.Name) {
+			continue
+		}
+		if match == nil || len(agent.Name) > len(match.Name) {
+			match = agent
+		}
+	}
+	return match
+}
+
+// WorktreePath returns where setup puts agent's worktree: a sibling of
+// the main repository named <repo>-<agent>.
+func (c *ProjectConfig) WorktreePath(agent string) string {
+	return filepath.Join(filepath.Dir(c.Root), fmt.Sprintf("%s-%s", filepath.Base(c.Root), agent))
+}
+
+// describeAgentNames formats the roster for error messages,
+// e.g. "forge, axiom, or jarvis".
+func describeAgentNames(names []string) string {
+	switch len(names) {
+	case 0:
+		return ""
+	case 1:
+		return names[0]
+	case 2:
+		return names[0] + " or " + names[1]
+	}
+	return strings.Join(names[:len(names)-1], ", ") + ", or " + names[len(names)-1]
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseProjectConfigFillsDefaults(t *testing.T) {
+	cfg, err := ParseProjectConfig([]byte(`
+name: monorepo
+agents:
+  - name: forge
+    color: red
+    role: Backend
+  - name: lint
+    branch: lint-base
+`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if cfg.Name != "monorepo" {
+		t.Errorf("Name = %q, want monorepo", cfg.Name)
+	}
+	if got := cfg.Agent("forge").Branch; got != "forge-worktree" {
+		t.Errorf("forge branch = %q, want forge-worktree", got)
+	}
+	if got := cfg.Agent("lint").Branch; got != "lint-base" {
+		t.Errorf("lint branch = %q, want lint-base", got)
+	}
+	if cfg.Agent("axiom") != nil {
+		t.Error("axiom should not be in a custom roster")
+	}
+}
+
+func TestParseProjectConfigRejectsBadRosters(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+	}{
+		{"no agents", "agents: []"},
+		{"uppercase name", "agents: [{name: Forge}]"},
+		{"name with slash", "agents: [{name: a/b}]"},
+		{"duplicate name", "agents: [{name: forge}, {name: forge}]"},
+		{"shared branch", "agents: [{name: a, branch: x}, {name: b, branch: x}]"},
+		{"unknown color", "agents: [{name: forge, color: plaid}]"},
+		{"unknown key", "agents: [{name: forge, colour: red}]"},
+		{"project name with slash", "name: ../../etc\nagents: [{name: forge}]"},
+		{"project name dot dot", "name: ..\nagents: [{name: forge}]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseProjectConfig([]byte(tt.yaml)); err == nil {
+				t.Errorf("expected error for %q", tt.yaml)
+			}
+		})
+	}
+}
+
+func TestLoadProjectConfigPrefersPersonalFile(t *testing.T) {
+	repo := newTestRepo(t)
+
+	cfg, err := LoadProjectConfig(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Source != "" || len(cfg.Agents) != 3 || cfg.Name != "project" {
+		t.Errorf("expected default roster named project, got %+v", cfg)
+	}
+
+	repoFile := filepath.Join(repo, ProjectConfigFile)
+	os.WriteFile(repoFile, []byte("agents: [{name: alpha}, {name: beta}]\n"), 0644)
+	cfg, err = LoadProjectConfig(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Source != repoFile || strings.Join(cfg.AgentNames(), ",") != "alpha,beta" {
+		t.Errorf("expected repo roster, got %v from %s", cfg.AgentNames(), cfg.Source)
+	}
+
+	personalFile := filepath.Join(agenterDir(), "projects", "project.yaml")
+	os.MkdirAll(filepath.Dir(personalFile), 0755)
+	os.WriteFile(personalFile, []byte("agents: [{name: solo}]\n"), 0644)
+	cfg, err = LoadProjectConfig(repo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Source != personalFile || strings.Join(cfg.AgentNames(), ",") != "solo" {
+		t.Errorf("expected personal roster, got %v from %s", cfg.AgentNames(), cfg.Source)
+	}
+}
+
+func TestAgentForDirPrefersLongestName(t *testing.T) {
+	cfg, err := ParseProjectConfig([]byte("agents: [{name: bot}, {name: my-bot}, {name: forge}]"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"/src/app-forge":  "forge",
+		"/src/app-bot":    "bot",
+		"/src/app-my-bot": "my-bot",
+		"/src/app-robot":  "",
+		"/src/app":        "",
+	}
+	for dir, want := range tests {
+		got := ""
+		if agent := cfg.AgentForDir(dir); agent != nil {
+			got = agent.Name
+		}
+		if got != want {
+			t.Errorf("AgentForDir(%q) = %q, want %q", dir, got, want)
+		}
+	}
+}
+
+func TestDescribeAgentNames(t *testing.T) {
+	tests := []struct {
+		names []string
+		want  string
+	}{
+		{[]string{"forge"}, "forge"},
+		{[]string{"forge", "axiom"}, "forge or axiom"},
+		{[]string{"forge", "axiom", "jarvis"}, "forge, axiom, or jarvis"},
+	}
+	for _, tt := range tests {
+		if got := describeAgentNames(tt.names); got != tt.want {
+			t.Errorf("describeAgentNames(%v) = %q, want %q", tt.names, got, tt.want)
+		}
+	}
+}
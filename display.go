@@ -1,9 +1,11 @@
 package main
 
 import (
+	"bufio"
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/fatih/color"
 )
@@ -58,18 +60,17 @@ func PrintStep(step int, total int, text string) {
 	fmt.Printf("[%d/%d] %s\n", step, total, text)
 }
 
-// PrintAgent prints an agent name with appropriate styling
+// PrintAgent prints an agent name in the color from its roster entry
 func PrintAgent(agent string) string {
-	switch agent {
-	case "forge":
-		return color.RedString(agent)
-	case "axiom":
-		return color.BlueString(agent)
-	case "jarvis":
-		return color.GreenString(agent)
-	default:
+	entry := CurrentConfig().Agent(agent)
+	if entry == nil {
+		return agent
+	}
+	attr, ok := agentColors[entry.Color]
+	if !ok {
 		return agent
 	}
+	return color.New(attr).Sprint(agent)
 }
 
 // PrintCommand prints a command that will be executed
@@ -95,3 +96,30 @@ func FormatPath(path string) string {
 	}
 	return path
 }
+
+// FormatAge describes how long ago t was, e.g. "5m ago" or "3d ago".
+// Anything older than a month is shown as a date.
+func FormatAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm ago", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh ago", int(d.Hours()))
+	case d < 30*24*time.Hour:
+		return fmt.Sprintf("%dd ago", int(d.Hours()/24))
+	default:
+		return t.Local().Format("2006-01-02")
+	}
+}
+
+// Confirm asks a yes/no question on the terminal. Anything but y or yes,
+// including no input at all, is a no.
+func Confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestFormatPathReplacesHomeDirectory(t *testing.T) {
@@ -79,3 +80,23 @@ func TestPrintAgentReturnsColoredNames(t *testing.T) {
 		})
 	}
 }
+
+func TestFormatAgeUsesLargestUnit(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		t    time.Time
+		want string
+	}{
+		{now.Add(-10 * time.Second), "just now"},
+		{now.Add(-5 * time.Minute), "5m ago"},
+		{now.Add(-3 * time.Hour), "3h ago"},
+		{now.Add(-50 * time.Hour), "2d ago"},
+		{time.Date(2020, 1, 2, 12, 0, 0, 0, time.Local), "2020-01-02"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatAge(tt.t); got != tt.want {
+			t.Errorf("FormatAge(%v) = %q, want %q", tt.t, got, tt.want)
+		}
+	}
+}
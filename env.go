@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var envKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// agentEnv builds the environment for agent running in worktree. Later
+// entries win, so the order is: our own environment, the agent's env
+// file, its env from the project config, and finally the AGENTER_*
+// variables, which describe who the agent is and can't be overridden.
+func agentEnv(cfg *ProjectConfig, agent *AgentConfig, worktree string) ([]string, error) {
+	env := os.Environ()
+
+	if agent.EnvFile != "" {
+		path := expandHome(agent.EnvFile)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(worktree, path)
+		}
+		vars, err := readEnvFile(path)
+		if os.IsNotExist(err) {
+			PrintWarning("Env file %s not found", FormatPath(path))
+		} else if err != nil {
+			return nil, err
+		} else {
+			env = append(env, vars...)
+		}
+	}
+
+	env = append(env, agent.Environ()...)
+	return append(env, agenterVars(cfg, agent, worktree)...), nil
+}
+
+// agenterVars describes the agent and its peers to Claude and any
+// scripts it runs.
+func agenterVars(cfg *ProjectConfig, agent *AgentConfig, worktree string) []string {
+	vars := []string{
+		"WHO_AM_I=" + agent.Name,
+		"AGENTER_AGENT=" + agent.Name,
+		"AGENTER_PROJECT=" + cfg.Name,
+		"AGENTER_MAIN_REPO=" + cfg.Root,
+		"AGENTER_WORKTREE=" + worktree,
+		"AGENTER_BASE_BRANCH=" + agent.Branch,
+	}
+
+	paths, err := agentWorktreePaths(cfg)
+	if err != nil {
+		LogDebug("Could not list peer worktrees: %v", err)
+	}
+
+	var peers []string
+	for _, peer := range cfg.Agents {
+		if peer.Name == agent.Name {
+			continue
+		}
+		peers = append(peers, peer.Name)
+
+		prefix := "AGENTER_PEER_" + envName(peer.Name)
+		path, ok := paths[peer.Name]
+		if !ok {
+			path = cfg.WorktreePath(peer.Name)
+		}
+		vars = append(vars,
+			prefix+"_WORKTREE="+path,
+			prefix+"_BRANCH="+peer.Branch)
+	}
+	return append(vars, "AGENTER_PEERS="+strings.Join(peers, ","))
+}
+
+// envName turns an agent name into the form used in variable names,
+// e.g. "code-review" becomes "CODE_REVIEW".
+func envName(agent string) string {
+	return strings.ToUpper(strings.ReplaceAll(agent, "-", "_"))
+}
+
+// expandHome replaces a leading ~/ with the home directory.
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		return filepath.Join(os.Getenv("HOME"), path[1:])
+	}
+	return path
+}
+
+// readEnvFile parses a .env file into KEY=value pairs. It understands
+// comments, an optional "export " prefix and single or double quotes.
+func readEnvFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var vars []string
+	scanner := bufio.NewScanner(f)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || !envKeyPattern.MatchString(key) {
+			return nil, fmt.Errorf("%s:%d: expected KEY=value", FormatPath(path), lineNum)
+		}
+
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %v", FormatPath(path), lineNum, err)
+		}
+		vars = append(vars, key+"="+value)
+	}
+	return vars, scanner.Err()
+}
+
+// parseEnvValue unquotes one .env value. Double quotes allow \n and
+// friends, single quotes are literal, and unquoted values end at " #".
+func parseEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch value[0] {
+	case '"':
+		end := strings.LastIndex(value, `"`)
+		if end == 0 {
+			return "", fmt.Errorf("unterminated double quote")
+		}
+		inner := value[1:end]
+		replacer := strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\"`, `"`, `\\`, `\`)
+		return replacer.Replace(inner), nil
+	case '\'':
+		end := strings.LastIndex(value, "'")
+		if end == 0 {
+			return "", fmt.Errorf("unterminated single quote")
+		}
+		return value[1:end], nil
+	}
+
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = value[:i]
+	}
+	return strings.TrimSpace(value), nil
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseEnvValue(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"", "", false},
+		{"plain", "plain", false},
+		{"plain # comment", "plain", false},
+		{"a#b", "a#b", false},
+		{`"two\nlines"`, "two\nlines", false},
+		{`"say \"hi\""`, `say "hi"`, false},
+		{`'$HOME \n'`, `$HOME \n`, false},
+		{`"open`, "", true},
+		{`'open`, "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseEnvValue(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseEnvValue(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseEnvValue(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestReadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	os.WriteFile(path, []byte("# secrets\n\nAPI_KEY=abc123\nexport REGION = eu-west\nGREETING=\"hello world\"\n"), 0644)
+
+	vars, err := readEnvFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"API_KEY=abc123", "REGION=eu-west", "GREETING=hello world"}
+	if strings.Join(vars, "|") != strings.Join(want, "|") {
+		t.Errorf("vars = %q, want %q", vars, want)
+	}
+
+	os.WriteFile(path, []byte("OK=1\nnot a variable\n"), 0644)
+	if _, err := readEnvFile(path); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("expected error on line 2, got %v", err)
+	}
+}
+
+func TestLaunchSetsAgenterEnvironment(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	os.WriteFile(filepath.Join(repo, ProjectConfigFile), []byte(`
+agents:
+  - name: forge
+    env_file: .env.agent
+    env:
+      FROM_CONFIG: config
+      AGENTER_AGENT: impostor
+  - name: code-review
+`), 0644)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	cfg := CurrentConfig()
+	forge := cfg.WorktreePath("forge")
+	os.WriteFile(filepath.Join(forge, ".env.agent"), []byte("FROM_FILE=file\nFROM_CONFIG=file\n"), 0644)
+
+	launched := installFakeClaude(t)
+	os.Chdir(repo)
+	if err := runLaunchImpl("forge", launchOptions{NoRecord: true}); err != nil {
+		t.Fatalf("launch failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(filepath.Dir(launched), "launched-env"))
+	env := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if key, value, ok := strings.Cut(line, "="); ok {
+			env[key] = value
+		}
+	}
+
+	want := map[string]string{
+		"WHO_AM_I":                          "forge",
+		"AGENTER_AGENT":                     "forge",
+		"AGENTER_PROJECT":                   "project",
+		"AGENTER_MAIN_REPO":                 repo,
+		"AGENTER_WORKTREE":                  forge,
+		"AGENTER_BASE_BRANCH":               "forge-worktree",
+		"AGENTER_PEERS":                     "code-review",
+		"AGENTER_PEER_CODE_REVIEW_WORKTREE": cfg.WorktreePath("code-review"),
+		"AGENTER_PEER_CODE_REVIEW_BRANCH":   "code-review-worktree",
+		"FROM_FILE":                         "file",
+		"FROM_CONFIG":                       "config",
+	}
+	for key, value := range want {
+		if env[key] != value {
+			t.Errorf("%s = %q, want %q", key, env[key], value)
+		}
+	}
+}
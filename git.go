@@ -0,0 +1,182 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// gitOutput runs git in dir and returns its stdout without trailing
+// newlines. Leading whitespace is kept since porcelain formats use it.
+// On failure the error includes git's stderr so callers can show it.
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimRight(string(output), "\n"), nil
+}
+
+// ghOutput runs the GitHub CLI in dir and returns its trimmed output.
+func ghOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("gh", args...)
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok && len(exitErr.Stderr) > 0 {
+			return "", fmt.Errorf("%s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// findMainRepo returns the main working tree for dir, which may be the
+// main repository itself or any of its linked worktrees.
+func findMainRepo(dir string) (string, error) {
+	commonDir, err := gitOutput(dir, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", fmt.Errorf("not in a git repository")
+	}
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(dir, commonDir)
+	}
+	commonDir, err = filepath.Abs(commonDir)
+	if err != nil {
+		return "", err
+	}
+	if filepath.Base(commonDir) != ".git" {
+		return "", fmt.Errorf("%s is a bare repository", commonDir)
+	}
+	return filepath.Dir(commonDir), nil
+}
+
+// worktreeInfo is one entry from 'git worktree list --porcelain'.
+type worktreeInfo struct {
+	Path     string
+	Head     string
+	Branch   string // short name, empty when detached
+	Detached bool
+	Bare     bool
+}
+
+// listWorktrees returns every worktree of the repository containing dir,
+// the main working tree first.
+func listWorktrees(dir string) ([]worktreeInfo, error) {
+	output, err := gitOutput(dir, "worktree", "list", "--porcelain")
+	if err != nil {
+		return nil, fmt.Errorf("could not list worktrees: %v", err)
+	}
+	return parseWorktreeList(output), nil
+}
+
+// parseWorktreeList parses porcelain output, where each worktree is a
+// block of "key value" lines separated by a blank line.
+func parseWorktreeList(output string) []worktreeInfo {
+	var worktrees []worktreeInfo
+	var current *worktreeInfo
+	for _, line := range strings.Split(output, "\n") {
+		key, value, _ := strings.Cut(line, " ")
+		switch key {
+		case "worktree":
+			worktrees = append(worktrees, worktreeInfo{Path: value})
+			current = &worktrees[len(worktrees)-1]
+		case "HEAD":
+			if current != nil {
+				current.Head = value
+			}
+		case "branch":
+			if current != nil {
+				current.Branch = strings.TrimPrefix(value, "refs/heads/")
+			}
+		case "detached":
+			if current != nil {
+				current.Detached = true
+			}
+		case "bare":
+			if current != nil {
+				current.Bare = true
+			}
+		}
+	}
+	return worktrees
+}
+
+// findAgentWorktree returns the worktree path for agent. Worktrees git
+// knows about win; otherwise we fall back to where setup would put it.
+func findAgentWorktree(cfg *ProjectConfig, agent string) (string, error) {
+	paths, err := agentWorktreePaths(cfg)
+	if err != nil {
+		return "", err
+	}
+	if path, ok := paths[agent]; ok {
+		return path, nil
+	}
+	return "", fmt.Errorf("no worktree for %s (expected %s)", agent, FormatPath(cfg.WorktreePath(agent)))
+}
+
+// agentWorktreePaths maps each agent that has a worktree to its path,
+// using a single 'git worktree list'.
+func agentWorktreePaths(cfg *ProjectConfig) (map[string]string, error) {
+	worktrees, err := listWorktrees(cfg.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make(map[string]string)
+	for _, wt := range worktrees {
+		if wt.Path == cfg.Root || wt.Bare {
+			continue
+		}
+		if match := cfg.AgentForDir(wt.Path); match != nil {
+			if _, seen := paths[match.Name]; !seen {
+				paths[match.Name] = wt.Path
+			}
+		}
+	}
+	for _, agent := range cfg.Agents {
+		if _, ok := paths[agent.Name]; ok {
+			continue
+		}
+		if path := cfg.WorktreePath(agent.Name); HasGitRepository(path) {
+			paths[agent.Name] = path
+		}
+	}
+	return paths, nil
+}
+
+// refExists reports whether ref resolves to a commit in dir.
+func refExists(dir, ref string) bool {
+	_, err := gitOutput(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	return err == nil
+}
+
+// aheadBehind counts commits on from that aren't on to, and the reverse.
+func aheadBehind(dir, from, to string) (ahead int, behind int, err error) {
+	output, err := gitOutput(dir, "rev-list", "--left-right", "--count", from+"..."+to)
+	if err != nil {
+		return 0, 0, err
+	}
+	if _, err := fmt.Sscanf(output, "%d %d", &ahead, &behind); err != nil {
+		return 0, 0, fmt.Errorf("unexpected rev-list output %q", output)
+	}
+	return ahead, behind, nil
+}
+
+// gitCommonDir returns the git dir shared by all worktrees of the
+// repository containing dir.
+func gitCommonDir(dir string) (string, error) {
+	commonDir, err := gitOutput(dir, "rev-parse", "--git-common-dir")
+	if err != nil {
+		return "", fmt.Errorf("could not find git dir for %s: %v", dir, err)
+	}
+	if !filepath.IsAbs(commonDir) {
+		commonDir = filepath.Join(dir, commonDir)
+	}
+	return commonDir, nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// newTestRepo creates a git repository with one commit on main inside a
+// fresh temp dir and returns its path. HOME is pointed at a temp dir too
+// so tests never see the real ~/.agenter, and any roster loaded by the
+// test is dropped afterwards.
+func newTestRepo(t *testing.T) string {
+	t.Helper()
+
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	t.Cleanup(func() { activeConfig = nil })
+
+	repo := filepath.Join(t.TempDir(), "project")
+	if err := os.Mkdir(repo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	runTestGit(t, repo, "init", "-q", "-b", "main")
+	if err := os.WriteFile(filepath.Join(repo, "README.md"), []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runTestGit(t, repo, "add", ".")
+	runTestGit(t, repo, "commit", "-q", "-m", "initial")
+	return repo
+}
+
+// runTestGit runs git in dir and fails the test on error.
+func runTestGit(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, output)
+	}
+	return string(output)
+}
+
+func TestFindMainRepoFromWorktree(t *testing.T) {
+	repo := newTestRepo(t)
+	worktree := filepath.Join(filepath.Dir(repo), "project-forge")
+	runTestGit(t, repo, "worktree", "add", "-q", "-b", "forge-worktree", worktree)
+
+	for _, dir := range []string{repo, worktree, filepath.Join(repo, ".git")} {
+		got, err := findMainRepo(dir)
+		if err != nil {
+			t.Fatalf("findMainRepo(%q): %v", dir, err)
+		}
+		if got != repo {
+			t.Errorf("findMainRepo(%q) = %q, want %q", dir, got, repo)
+		}
+	}
+
+	if _, err := findMainRepo(t.TempDir()); err == nil {
+		t.Error("expected error outside a repository")
+	}
+}
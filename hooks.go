@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// hookEvents are the points in agenter's workflow that can run hooks.
+// A failing pre_* hook stops the operation; post_* failures only warn.
+var hookEvents = []string{
+	"post_setup",
+	"pre_launch",
+	"post_exit",
+	"post_topic_make",
+	"pre_push",
+	"post_push",
+	"post_next",
+}
+
+// hookCommands is one or more shell commands. The config accepts either
+// a single string or a list.
+type hookCommands []string
+
+// UnmarshalYAML accepts a string as a one-command list.
+func (h *hookCommands) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*h = hookCommands{node.Value}
+		return nil
+	}
+	var cmds []string
+	if err := node.Decode(&cmds); err != nil {
+		return err
+	}
+	*h = cmds
+	return nil
+}
+
+// validateHooks rejects hook names agenter never runs.
+func validateHooks(hooks map[string]hookCommands) error {
+	for event := range hooks {
+		known := false
+		for _, name := range hookEvents {
+			known = known || name == event
+		}
+		if !known {
+			return fmt.Errorf("unknown hook %q (must be one of %s)", event, strings.Join(hookEvents, ", "))
+		}
+	}
+	return nil
+}
+
+// runHooks runs the project's commands for event in dir, with agent's
+// environment plus AGENTER_HOOK and any extra KEY=value pairs. Output is
+// shown line by line as it arrives.
+func runHooks(event string, agent *AgentConfig, dir string, extra ...string) error {
+	cfg := CurrentConfig()
+	cmds := cfg.Hooks[event]
+	if len(cmds) == 0 || agent == nil {
+		return nil
+	}
+
+	env, err := agentEnv(cfg, agent, dir)
+	if err != nil {
+		return err
+	}
+	env = append(append(env, "AGENTER_HOOK="+event), extra...)
+
+	for _, command := range cmds {
+		PrintCommand(command)
+		cmd := exec.Command("sh", "-c", command)
+		cmd.Dir = dir
+		cmd.Env = env
+		out := &hookOutput{event: event}
+		cmd.Stdout = out
+		cmd.Stderr = out
+		err := cmd.Run()
+		out.Flush()
+		if err != nil {
+			err = fmt.Errorf("%s hook failed: %v", event, err)
+			if strings.HasPrefix(event, "pre_") {
+				return err
+			}
+			PrintWarning("%v", err)
+		}
+	}
+	return nil
+}
+
+// hookOutput passes a hook's output to PrintInfo a line at a time.
+type hookOutput struct {
+	event   string
+	pending []byte
+}
+
+func (o *hookOutput) Write(p []byte) (int, error) {
+	o.pending = append(o.pending, p...)
+	for {
+		i := bytes.IndexByte(o.pending, '\n')
+		if i < 0 {
+			return len(p), nil
+		}
+		PrintInfo("%s: %s", o.event, strings.TrimRight(string(o.pending[:i]), "\r"))
+		o.pending = o.pending[i+1:]
+	}
+}
+
+// Flush prints a final line that didn't end in a newline.
+func (o *hookOutput) Flush() {
+	if len(o.pending) > 0 {
+		PrintInfo("%s: %s", o.event, o.pending)
+		o.pending = nil
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHooksConfig(t *testing.T) {
+	cfg, err := ParseProjectConfig([]byte(`
+hooks:
+  post_setup: npm install
+  pre_push:
+    - make lint
+    - make test
+agents:
+  - name: forge
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(cfg.Hooks["post_setup"], "|"); got != "npm install" {
+		t.Errorf("post_setup = %q", got)
+	}
+	if got := strings.Join(cfg.Hooks["pre_push"], "|"); got != "make lint|make test" {
+		t.Errorf("pre_push = %q", got)
+	}
+
+	if _, err := ParseProjectConfig([]byte("hooks:\n  pre_pull: make\nagents:\n  - name: forge\n")); err == nil {
+		t.Error("expected unknown hook to be rejected")
+	}
+}
+
+func TestHooksRunAroundWorkflow(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	runTestGit(t, repo, "init", "-q", "--bare", origin)
+	runTestGit(t, repo, "remote", "add", "origin", origin)
+	os.WriteFile(filepath.Join(repo, ProjectConfigFile), []byte(`
+hooks:
+  post_setup: echo "$AGENTER_AGENT $PWD" >> "$HOME/setup"
+  post_topic_make: echo "$AGENTER_TOPIC $AGENTER_BRANCH" > "$HOME/topic"
+  pre_push: test ! -e "$HOME/block-push"
+  post_push: echo "$AGENTER_HOOK $AGENTER_BRANCH" > "$HOME/pushed"
+  post_next: echo "$AGENTER_PREVIOUS_BRANCH" > "$HOME/next"
+agents:
+  - name: forge
+  - name: axiom
+`), 0644)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	home := os.Getenv("HOME")
+	forge := CurrentConfig().WorktreePath("forge")
+
+	read := func(name string) string {
+		data, _ := os.ReadFile(filepath.Join(home, name))
+		return strings.TrimSpace(string(data))
+	}
+
+	want := "forge " + forge + "\naxiom " + CurrentConfig().WorktreePath("axiom")
+	if got := read("setup"); got != want {
+		t.Errorf("post_setup ran as:\n%s\nwant:\n%s", got, want)
+	}
+
+	// Existing worktrees aren't set up again
+	os.Remove(filepath.Join(home, "setup"))
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	if got := read("setup"); got != "" {
+		t.Errorf("post_setup reran for existing worktrees: %s", got)
+	}
+
+	os.Chdir(forge)
+	if err := runWorktreeMakeImpl("fix", ""); err != nil {
+		t.Fatal(err)
+	}
+	if got := read("topic"); got != "fix forge-worktree-fix" {
+		t.Errorf("post_topic_make got %q", got)
+	}
+
+	// A failing pre_push stops the push
+	os.WriteFile(filepath.Join(home, "block-push"), nil, 0644)
+	if err := runWorktreePushImpl(pushOptions{}); err == nil || !strings.Contains(err.Error(), "pre_push hook failed") {
+		t.Errorf("expected pre_push to abort, got %v", err)
+	}
+	if refExists(origin, "refs/heads/forge-worktree-fix") {
+		t.Error("branch was pushed despite failing pre_push")
+	}
+
+	os.Remove(filepath.Join(home, "block-push"))
+	if err := runWorktreePushImpl(pushOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := read("pushed"); got != "post_push forge-worktree-fix" {
+		t.Errorf("post_push got %q", got)
+	}
+
+	if err := runWorktreeNextImpl("", nextOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := read("next"); got != "forge-worktree-fix" {
+		t.Errorf("post_next got %q", got)
+	}
+}
+
+func TestLaunchHooks(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	os.WriteFile(filepath.Join(repo, ProjectConfigFile), []byte(`
+hooks:
+  pre_launch: test ! -e "$HOME/block-launch"
+  post_exit: echo "exit $AGENTER_EXIT_STATUS" > "$HOME/exited"
+agents:
+  - name: forge
+`), 0644)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	launched := installFakeClaude(t)
+	home := filepath.Dir(launched)
+	os.Chdir(repo)
+
+	os.WriteFile(filepath.Join(home, "block-launch"), nil, 0644)
+	if err := runLaunchImpl("forge", launchOptions{NoRecord: true}); err == nil {
+		t.Error("expected pre_launch to abort the launch")
+	}
+	if _, err := os.Stat(launched); err == nil {
+		t.Error("claude ran despite failing pre_launch")
+	}
+
+	os.Remove(filepath.Join(home, "block-launch"))
+	if err := runLaunchImpl("forge", launchOptions{NoRecord: true}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(home, "exited")); strings.TrimSpace(string(data)) != "exit 0" {
+		t.Errorf("post_exit got %q", data)
+	}
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// defaultInstructionsTemplate is rendered into each worktree so the
+// agent knows who it is without being told by hand.
+const defaultInstructionsTemplate = `# You are {{.Agent.Name}}
+
+<!-- Generated by agenter for {{.Project}}. 'agenter setup' overwrites this file. -->
+
+You are {{.Agent.Name}}, one of {{len .Agents}} agents working on {{.Project}} in parallel.
+{{- if .Agent.Role}} Your role: {{.Agent.Role}}.{{end}}
+
+You work in {{.Worktree}}. Your base branch is ` + "`{{.Agent.Branch}}`" + `, and each piece of work
+gets its own topic branch off it. Never edit files in another agent's worktree.
+{{- if .Peers}}
+
+## Other agents
+{{range .Peers}}
+- **{{.Name}}**{{if .Role}} ({{.Role}}){{end}}: branch ` + "`{{.Branch}}`" + ` in {{.Worktree}}
+{{- end}}
+{{- end}}
+
+## Workflow
+
+- ` + "`agenter worktree make <topic>`" + ` creates a topic branch from your base branch
+- ` + "`agenter worktree push`" + ` pushes the topic branch for review
+- ` + "`agenter worktree next [topic]`" + ` returns to your base branch, optionally starting the next topic
+- ` + "`agenter status`" + ` shows what every agent is working on
+
+Coordinate with the other agents through GitHub issues and pull requests.
+{{- if .Rules}}
+
+## Project rules
+{{range .Rules}}
+- {{.}}
+{{- end}}
+{{- end}}
+`
+
+// instructionsPeer describes another agent in the instructions.
+type instructionsPeer struct {
+	Name     string
+	Role     string
+	Branch   string
+	Worktree string
+}
+
+// instructionsData is what the instructions template can refer to.
+type instructionsData struct {
+	Project  string
+	Agent    AgentConfig
+	Agents   []AgentConfig
+	Worktree string
+	MainRepo string
+	Peers    []instructionsPeer
+	Rules    []string
+}
+
+// renderInstructions fills in the project's instructions template for
+// agent in worktree.
+func renderInstructions(cfg *ProjectConfig, agent *AgentConfig, worktree string) (string, error) {
+	text := defaultInstructionsTemplate
+	if cfg.InstructionsTemplate != "" {
+		path := expandHome(cfg.InstructionsTemplate)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(cfg.Root, path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("could not read instructions template: %v", err)
+		}
+		text = string(data)
+	}
+
+	tmpl, err := template.New("instructions").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("bad instructions template: %v", err)
+	}
+
+	data := instructionsData{
+		Project:  cfg.Name,
+		Agent:    *agent,
+		Agents:   cfg.Agents,
+		Worktree: worktree,
+		MainRepo: cfg.Root,
+		Rules:    cfg.Rules,
+	}
+	paths, err := agentWorktreePaths(cfg)
+	if err != nil {
+		LogDebug("Could not list peer worktrees: %v", err)
+	}
+	for _, peer := range cfg.Agents {
+		if peer.Name == agent.Name {
+			continue
+		}
+		path, ok := paths[peer.Name]
+		if !ok {
+			path = cfg.WorktreePath(peer.Name)
+		}
+		data.Peers = append(data.Peers, instructionsPeer{peer.Name, peer.Role, peer.Branch, path})
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("bad instructions template: %v", err)
+	}
+	return out.String(), nil
+}
+
+// writeAgentInstructions renders agent's instructions into the file its
+// backend reads and keeps that file out of commits. It returns the path
+// written, or "" when the backend has no instructions file or the repo
+// already tracks one by that name.
+func writeAgentInstructions(cfg *ProjectConfig, agent *AgentConfig, worktree string) (string, error) {
+	backend, err := cfg.Backend(agent)
+	if err != nil {
+		return "", err
+	}
+	if backend.Instructions == "" {
+		return "", nil
+	}
+
+	// Never overwrite the project's own instructions
+	if _, err := gitOutput(worktree, "ls-files", "--error-unmatch", "--", backend.Instructions); err == nil {
+		PrintWarning("%s is tracked in git, not generating instructions for %s", backend.Instructions, agent.Name)
+		return "", nil
+	}
+
+	content, err := renderInstructions(cfg, agent, worktree)
+	if err != nil {
+		return "", err
+	}
+	if err := excludeFromGit(worktree, backend.Instructions); err != nil {
+		return "", err
+	}
+	path := filepath.Join(worktree, backend.Instructions)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// excludeFromGit adds name to the repository's info/exclude, which every
+// worktree shares, unless it's already there.
+func excludeFromGit(worktree string, name string) error {
+	commonDir, err := gitCommonDir(worktree)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(commonDir, "info", "exclude")
+
+	pattern := "/" + name
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	for _, line := range strings.Split(string(existing), "\n") {
+		if strings.TrimSpace(line) == pattern {
+			return nil
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if len(existing) > 0 && !bytes.HasSuffix(existing, []byte("\n")) {
+		pattern = "\n" + pattern
+	}
+	_, err = fmt.Fprintf(f, "%s\n", pattern)
+	return err
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetupWritesAgentInstructions(t *testing.T) {
+	repo := newTestRepo(t)
+	os.WriteFile(filepath.Join(repo, ProjectConfigFile), []byte(`
+rules:
+  - Run make test before pushing
+agents:
+  - name: forge
+    role: Backend and API work
+  - name: lint
+    role: Lint and cleanup
+    backend: codex
+`), 0644)
+	runTestGit(t, repo, "add", ProjectConfigFile)
+	runTestGit(t, repo, "commit", "-q", "-m", "config")
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	cfg := CurrentConfig()
+	forge := cfg.WorktreePath("forge")
+
+	data, err := os.ReadFile(filepath.Join(forge, "CLAUDE.local.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"You are forge, one of 2 agents working on project",
+		"Your role: Backend and API work.",
+		"Your base branch is `forge-worktree`",
+		"- **lint** (Lint and cleanup): branch `lint-worktree` in " + cfg.WorktreePath("lint"),
+		"agenter worktree make <topic>",
+		"- Run make test before pushing",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("instructions missing %q:\n%s", want, data)
+		}
+	}
+
+	// Each backend gets the file it reads
+	if _, err := os.Stat(filepath.Join(cfg.WorktreePath("lint"), "AGENTS.override.md")); err != nil {
+		t.Errorf("codex instructions not written: %v", err)
+	}
+
+	// The generated files never show up as changes, and rerunning setup
+	// doesn't repeat the exclude patterns
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	if status := runTestGit(t, forge, "status", "--porcelain"); status != "" {
+		t.Errorf("worktree not clean:\n%s", status)
+	}
+	exclude, _ := os.ReadFile(filepath.Join(repo, ".git", "info", "exclude"))
+	if n := strings.Count(string(exclude), "/CLAUDE.local.md\n"); n != 1 {
+		t.Errorf("exclude lists CLAUDE.local.md %d times:\n%s", n, exclude)
+	}
+}
+
+func TestSetupKeepsTrackedInstructions(t *testing.T) {
+	repo := newTestRepo(t)
+	os.WriteFile(filepath.Join(repo, "CLAUDE.local.md"), []byte("ours\n"), 0644)
+	os.WriteFile(filepath.Join(repo, "agents.tmpl"), []byte("{{.Agent.Name}} of {{.Project}}\n"), 0644)
+	os.WriteFile(filepath.Join(repo, ProjectConfigFile), []byte(`
+instructions_template: agents.tmpl
+backends:
+  claude:
+    instructions: NOTES.local.md
+agents:
+  - name: forge
+  - name: axiom
+`), 0644)
+	runTestGit(t, repo, "add", ".")
+	runTestGit(t, repo, "commit", "-q", "-m", "tracked instructions")
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	forge := CurrentConfig().WorktreePath("forge")
+	if data, _ := os.ReadFile(filepath.Join(forge, "NOTES.local.md")); string(data) != "forge of project\n" {
+		t.Errorf("custom template rendered %q", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(forge, "CLAUDE.local.md")); string(data) != "ours\n" {
+		t.Errorf("tracked file changed to %q", data)
+	}
+
+	// A backend pointed at a tracked file is left alone
+	os.WriteFile(filepath.Join(repo, ProjectConfigFile), []byte(`
+backends:
+  claude:
+    instructions: CLAUDE.local.md
+agents:
+  - name: forge
+  - name: axiom
+`), 0644)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(forge, "CLAUDE.local.md")); string(data) != "ours\n" {
+		t.Errorf("tracked file overwritten with %q", data)
+	}
+}
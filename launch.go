@@ -0,0 +1,304 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// loadProjectByRef loads the project config for ref, which is either a
+// name from the registry or a path inside a repository.
+func loadProjectByRef(ref string) (*ProjectConfig, error) {
+	if info, err := os.Stat(ref); err == nil && info.IsDir() {
+		root, err := findMainRepo(ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", ref, err)
+		}
+		return LoadProjectConfig(root)
+	}
+
+	g, err := LoadGlobalConfig()
+	if err != nil {
+		return nil, err
+	}
+	matches := g.ProjectsNamed(ref)
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("unknown project %s. Run 'agenter list' to see projects", ref)
+	case 1:
+		return LoadProjectConfig(matches[0].Path)
+	}
+
+	paths := make([]string, len(matches))
+	for i, entry := range matches {
+		paths[i] = FormatPath(entry.Path)
+	}
+	return nil, fmt.Errorf("project name %s is ambiguous (%s); pass a path instead", ref, strings.Join(paths, ", "))
+}
+
+// resolveAgentWorktree finds the directory agent should run in. From the
+// agent's own worktree that's the current directory; from anywhere else
+// in the project (or with --project) we look the worktree up.
+func resolveAgentWorktree(agent string) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current directory: %v", err)
+	}
+	if IsAgentWorkspaceDir(cwd, agent) == nil {
+		return cwd, nil
+	}
+
+	cfg := CurrentConfig()
+	if cfg.Root == "" {
+		// Not in a project at all, so keep the original guard message
+		return "", IsAgentWorkspaceDir(cwd, agent)
+	}
+	return findAgentWorktree(cfg, agent)
+}
+
+// launchOptions are the command-line choices for one launch.
+type launchOptions struct {
+	// Project names the project when launching from outside it.
+	Project string
+	// Args go to the agent's tool after its configured args.
+	Args []string
+	// NoRecord skips writing a session transcript.
+	NoRecord bool
+	// Sandbox runs the tool with the filesystem locked down.
+	Sandbox bool
+	// Timeout overrides the configured time box when set.
+	Timeout time.Duration
+}
+
+// prepareAgentDir picks the project and worktree agent runs in, with
+// the same guard that launch has always applied.
+func prepareAgentDir(agent string, project string) (string, error) {
+	if project != "" {
+		cfg, err := loadProjectByRef(project)
+		if err != nil {
+			return "", err
+		}
+		activeConfig = cfg
+	}
+
+	// Validate agent name
+	if err := IsKnownAgentName(agent); err != nil {
+		return "", err
+	}
+
+	// Find the worktree to run in
+	dir, err := resolveAgentWorktree(agent)
+	if err != nil {
+		return "", err
+	}
+
+	// The guard applies to wherever the agent actually runs
+	if err := IsAgentWorkspaceDir(dir, agent); err != nil {
+		return "", err
+	}
+	if cwd, _ := os.Getwd(); filepath.Clean(cwd) != filepath.Clean(dir) {
+		PrintInfo("Using worktree %s", FormatPath(dir))
+	}
+
+	if root := CurrentConfig().Root; root != "" {
+		if err := TouchProject(root); err != nil {
+			LogDebug("Could not update last launch time: %v", err)
+		}
+	}
+	return dir, nil
+}
+
+// agentCommand builds the command for agent in dir from its backend:
+// the interactive command, or the headless one when prompt isn't empty.
+// The agent's configured args and then extra follow, and the command
+// gets the agent's environment and optionally its sandbox.
+func agentCommand(agent string, dir string, extra []string, prompt string, sandbox bool) (*exec.Cmd, error) {
+	cfg := CurrentConfig()
+	agentCfg := cfg.Agent(agent)
+	backend, err := cfg.Backend(agentCfg)
+	if err != nil {
+		return nil, err
+	}
+	template := backend.Command
+	if prompt != "" {
+		if backend.Run == "" {
+			return nil, fmt.Errorf("backend %s has no headless run command", backend.Name)
+		}
+		template = backend.Run
+	}
+	words, promptInArgs := expandBackendTemplate(template, map[string]string{
+		"agent":        agent,
+		"worktree":     dir,
+		"instructions": backend.Instructions,
+		"prompt":       prompt,
+	})
+	if len(words) == 0 {
+		return nil, fmt.Errorf("backend %s has an empty command", backend.Name)
+	}
+	// The headless command may run a different binary than the
+	// interactive one
+	path, err := lookupBinary(words[0])
+	if err != nil {
+		return nil, err
+	}
+	args := append(append(words[1:], agentCfg.Args...), extra...)
+	LogDebug("Running %s %s", path, strings.Join(args, " "))
+
+	env, err := agentEnv(cfg, agentCfg, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(path, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	if prompt != "" && !promptInArgs {
+		// Long prompt files aren't limited by the size of the command line
+		cmd.Stdin = strings.NewReader(prompt)
+	}
+	if sandbox {
+		if err := applySandbox(cmd, cfg); err != nil {
+			return nil, err
+		}
+	}
+	return cmd, nil
+}
+
+// runLaunchImpl runs the launch command
+func runLaunchImpl(agent string, opts launchOptions) error {
+	dir, err := prepareAgentDir(agent, opts.Project)
+	if err != nil {
+		return err
+	}
+
+	// One agent per worktree, or the two sessions share .claude/
+	lock, err := acquireAgentLock(dir, agent)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	agentCfg := CurrentConfig().Agent(agent)
+	if err := runHooks("pre_launch", agentCfg, dir); err != nil {
+		return err
+	}
+
+	limits, err := agentLimits(agentCfg, opts.Timeout)
+	if err != nil {
+		return err
+	}
+	cmd, err := agentCommand(agent, dir, opts.Args, "", opts.Sandbox)
+	if err != nil {
+		return err
+	}
+	if err := applyLimits(cmd, limits); err != nil {
+		return err
+	}
+	backend, _ := CurrentConfig().Backend(agentCfg)
+	PrintSuccess("Launching %s as %s...", backend.Title(), PrintAgent(agent))
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	// Record the session transcript alongside the agent's other sessions
+	var session *sessionMeta
+	if !opts.NoRecord {
+		session, err = startSession(CurrentConfig(), agent, dir)
+		if err != nil {
+			PrintWarning("Could not start session log: %v", err)
+		} else if !recordCommand(cmd, session.Path) {
+			PrintWarning("script(1) not found, session will not be recorded")
+			os.Remove(session.Path)
+			session = nil
+		} else {
+			LogDebug("Recording session to %s", session.Path)
+		}
+	}
+
+	err = runForeground(cmd, limits)
+	outcome := timeBoxOutcome(err, dir, agent)
+	runHooks("post_exit", agentCfg, dir, "AGENTER_EXIT_STATUS="+exitStatus(err))
+
+	if session != nil {
+		if finishErr := finishSession(session, exitStatus(err)); finishErr != nil {
+			PrintWarning("Could not finish session log: %v", finishErr)
+		}
+		if outcome != "" {
+			appendSessionNote(session, outcome)
+		}
+		PrintInfo("Session recorded to %s", FormatPath(session.Path))
+	}
+	return err
+}
+
+// agentLimits returns agent's configured limits, with timeout replacing
+// the configured one when set.
+func agentLimits(agent *AgentConfig, timeout time.Duration) (resourceLimits, error) {
+	limits, err := CurrentConfig().LimitsFor(agent)
+	if err != nil {
+		return limits, err
+	}
+	if timeout > 0 {
+		limits.Timeout = timeout
+	}
+	return limits, nil
+}
+
+// runForeground runs an interactive child and waits for it. agenter has
+// to outlive the child to release its lock, so termination signals are
+// passed on rather than killing us first. SIGINT is only forwarded to a
+// child in its own process group; otherwise the terminal has already
+// delivered it to the whole foreground group.
+//
+// With a timeout, the child's process tree gets SIGINT when time is up
+// and SIGKILL once the grace period has passed too, and the result is a
+// *timeoutError.
+func runForeground(cmd *exec.Cmd, limits resourceLimits) error {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+	defer signal.Stop(signals)
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+
+	ownGroup := cmd.SysProcAttr != nil && cmd.SysProcAttr.Setpgid
+	var timeUp, graceUp <-chan time.Time
+	if limits.Timeout > 0 {
+		timer := time.NewTimer(limits.Timeout)
+		defer timer.Stop()
+		timeUp = timer.C
+	}
+
+	// Children that outlive their parent's exit are reparented, so the
+	// processes interrupted are remembered for the kill
+	var interrupted []int
+	for {
+		select {
+		case sig := <-signals:
+			if sig != syscall.SIGINT || ownGroup {
+				cmd.Process.Signal(sig)
+			}
+		case <-timeUp:
+			PrintWarning("Time is up after %s, interrupting", limits.Timeout)
+			interrupted = processTree(cmd.Process.Pid)
+			signalProcesses(interrupted, syscall.SIGINT)
+			graceUp = time.After(limits.Grace)
+		case <-graceUp:
+			PrintWarning("Still running %s later, killing it", limits.Grace)
+			signalProcesses(append(processTree(cmd.Process.Pid), interrupted...), syscall.SIGKILL)
+		case err := <-exited:
+			if interrupted != nil {
+				return &timeoutError{after: limits.Timeout}
+			}
+			return err
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// installFakeClaude puts a claude script first on PATH that appends its
+// working directory to $HOME/launched, and returns that log's path. Its
+// arguments and environment go to launched-args and launched-env.
+func installFakeClaude(t *testing.T) string {
+	t.Helper()
+	binDir := t.TempDir()
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	script := "#!/bin/sh\n" +
+		"pwd >> \"$HOME/launched\"\n" +
+		"echo \"$*\" > \"$HOME/launched-args\"\n" +
+		"env > \"$HOME/launched-env\"\n"
+	if err := os.WriteFile(filepath.Join(binDir, "claude"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(os.Getenv("HOME"), "launched")
+}
+
+func TestLaunchResolvesWorktreeFromAnywhere(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	cfg := CurrentConfig()
+	launched := installFakeClaude(t)
+	forge := cfg.WorktreePath("forge")
+
+	tests := []struct {
+		name    string
+		dir     string
+		project string
+	}{
+		{"from own worktree", forge, ""},
+		{"from main repo", repo, ""},
+		{"from subdirectory of main repo", filepath.Join(repo, ".git"), ""},
+		{"from another agent's worktree", cfg.WorktreePath("axiom"), ""},
+		{"from outside with project name", t.TempDir(), "project"},
+		{"from outside with project path", t.TempDir(), repo},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(launched)
+			os.Chdir(tt.dir)
+			if err := InitConfig(tt.dir); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := runLaunchImpl("forge", launchOptions{Project: tt.project}); err != nil {
+				t.Fatalf("launch failed: %v", err)
+			}
+			data, _ := os.ReadFile(launched)
+			if got := strings.TrimSpace(string(data)); got != forge {
+				t.Errorf("claude ran in %q, want %q", got, forge)
+			}
+		})
+	}
+}
+
+func TestLaunchOutsideProjectKeepsGuard(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	newTestRepo(t)
+	dir := t.TempDir()
+	os.Chdir(dir)
+	if err := InitConfig(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	err := runLaunchImpl("forge", launchOptions{})
+	if err == nil || !strings.Contains(err.Error(), "directories ending with '-forge'") {
+		t.Errorf("expected directory guard error, got %v", err)
+	}
+
+	if err := runLaunchImpl("forge", launchOptions{Project: "no-such-project"}); err == nil {
+		t.Error("expected unknown project to fail")
+	}
+}
+
+func TestLaunchGuardTellsOverlappingNamesApart(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	os.WriteFile(filepath.Join(repo, ProjectConfigFile), []byte("agents: [{name: bot}, {name: my-bot}]\n"), 0644)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	launched := installFakeClaude(t)
+	cfg := CurrentConfig()
+	bot, myBot := cfg.WorktreePath("bot"), cfg.WorktreePath("my-bot")
+
+	if err := IsAgentWorkspaceDir(myBot, "bot"); err == nil || !strings.Contains(err.Error(), "belongs to my-bot") {
+		t.Errorf("bot allowed in my-bot's worktree: %v", err)
+	}
+	if err := IsAgentWorkspaceDir(myBot, "my-bot"); err != nil {
+		t.Errorf("my-bot refused its own worktree: %v", err)
+	}
+
+	// From my-bot's worktree, bot still runs in its own
+	os.Chdir(myBot)
+	if err := runLaunchImpl("bot", launchOptions{NoRecord: true}); err != nil {
+		t.Fatalf("launch failed: %v", err)
+	}
+	data, _ := os.ReadFile(launched)
+	if got := strings.TrimSpace(string(data)); got != bot {
+		t.Errorf("bot ran in %q, want %q", got, bot)
+	}
+}
+
+func TestLaunchPassesConfiguredAndExtraArgs(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	os.WriteFile(filepath.Join(repo, ProjectConfigFile), []byte(`
+agents:
+  - name: forge
+  - name: review
+    args: [--permission-mode, plan]
+    env:
+      REVIEW_MODE: strict
+      REVIEW_HOME: ${HOME}/review
+`), 0644)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	launched := installFakeClaude(t)
+	home := filepath.Dir(launched)
+	os.Chdir(repo)
+
+	if err := runLaunchImpl("review", launchOptions{Args: []string{"--model", "x", "--resume"}}); err != nil {
+		t.Fatalf("launch failed: %v", err)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(home, "launched-args"))
+	if got := strings.TrimSpace(string(args)); got != "--permission-mode plan --model x --resume" {
+		t.Errorf("claude args = %q", got)
+	}
+	env, _ := os.ReadFile(filepath.Join(home, "launched-env"))
+	for _, want := range []string{"REVIEW_MODE=strict", "REVIEW_HOME=" + home + "/review"} {
+		if !strings.Contains(string(env), want+"\n") {
+			t.Errorf("claude env missing %s", want)
+		}
+	}
+}
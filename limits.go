@@ -0,0 +1,316 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// defaultGrace is how long an agent gets to wrap up after the SIGINT
+// that ends its time box.
+const defaultGrace = 30 * time.Second
+
+// LimitsConfig bounds an agent's sessions. Values are strings so they
+// read naturally in YAML: "2h", "150%", "4G".
+type LimitsConfig struct {
+	// Timeout ends the session after this long.
+	Timeout string `yaml:"timeout,omitempty"`
+	// Grace is the wait between SIGINT and SIGKILL. Defaults to 30s.
+	Grace string `yaml:"grace,omitempty"`
+	// CPU caps CPU use, as a percentage of one core ("150%") or a
+	// number of cores ("1.5"). Needs cgroup v2.
+	CPU string `yaml:"cpu,omitempty"`
+	// Memory caps memory use, e.g. "4G" or "512M".
+	Memory string `yaml:"memory,omitempty"`
+}
+
+// resourceLimits is a parsed LimitsConfig. Zero means unlimited.
+type resourceLimits struct {
+	Timeout    time.Duration
+	Grace      time.Duration
+	CPUPercent int
+	Memory     int64
+}
+
+// parse checks the limits and converts them to numbers.
+func (l LimitsConfig) parse() (resourceLimits, error) {
+	limits := resourceLimits{Grace: defaultGrace}
+	var err error
+	if l.Timeout != "" {
+		if limits.Timeout, err = time.ParseDuration(l.Timeout); err != nil || limits.Timeout <= 0 {
+			return limits, fmt.Errorf("invalid timeout %q", l.Timeout)
+		}
+	}
+	if l.Grace != "" {
+		if limits.Grace, err = time.ParseDuration(l.Grace); err != nil || limits.Grace < 0 {
+			return limits, fmt.Errorf("invalid grace period %q", l.Grace)
+		}
+	}
+	if l.CPU != "" {
+		if limits.CPUPercent, err = parseCPU(l.CPU); err != nil {
+			return limits, err
+		}
+	}
+	if l.Memory != "" {
+		if limits.Memory, err = parseSize(l.Memory); err != nil {
+			return limits, err
+		}
+	}
+	return limits, nil
+}
+
+// merge returns l with any fields set in override replaced.
+func (l LimitsConfig) merge(override LimitsConfig) LimitsConfig {
+	for _, f := range []struct{ dst, src *string }{
+		{&l.Timeout, &override.Timeout},
+		{&l.Grace, &override.Grace},
+		{&l.CPU, &override.CPU},
+		{&l.Memory, &override.Memory},
+	} {
+		if *f.src != "" {
+			*f.dst = *f.src
+		}
+	}
+	return l
+}
+
+// LimitsFor returns the limits for agent: the project's, with any the
+// agent sets itself taking precedence.
+func (c *ProjectConfig) LimitsFor(agent *AgentConfig) (resourceLimits, error) {
+	return c.Limits.merge(agent.Limits).parse()
+}
+
+// parseCPU reads "150%" or "1.5" (cores) as a percentage of one core.
+func parseCPU(s string) (int, error) {
+	if percent, ok := strings.CutSuffix(s, "%"); ok {
+		n, err := strconv.Atoi(percent)
+		if err != nil || n <= 0 {
+			return 0, fmt.Errorf("invalid CPU limit %q", s)
+		}
+		return n, nil
+	}
+	cores, err := strconv.ParseFloat(s, 64)
+	if err != nil || cores <= 0 {
+		return 0, fmt.Errorf("invalid CPU limit %q (use a percentage like 150%% or a number of cores)", s)
+	}
+	return int(cores * 100), nil
+}
+
+// parseSize reads a byte count with an optional K, M, G or T suffix.
+func parseSize(s string) (int64, error) {
+	units := map[string]int64{"K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40}
+	number, unit := strings.ToUpper(s), int64(1)
+	if len(number) > 0 {
+		if u, ok := units[number[len(number)-1:]]; ok {
+			number, unit = number[:len(number)-1], u
+		}
+	}
+	n, err := strconv.ParseInt(number, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid memory limit %q (use a size like 4G or 512M)", s)
+	}
+	return n * unit, nil
+}
+
+// applyLimits wraps cmd so its whole process tree stays within the CPU
+// and memory limits. A systemd scope puts it in its own cgroup; without
+// one, memory falls back to an address space rlimit and CPU can't be
+// capped.
+func applyLimits(cmd *exec.Cmd, limits resourceLimits) error {
+	if limits.CPUPercent == 0 && limits.Memory == 0 {
+		return nil
+	}
+
+	if systemdScopeAvailable() {
+		args := []string{"systemd-run", "--user", "--scope", "--quiet", "--collect"}
+		if limits.CPUPercent > 0 {
+			args = append(args, "-p", fmt.Sprintf("CPUQuota=%d%%", limits.CPUPercent))
+		}
+		if limits.Memory > 0 {
+			args = append(args, "-p", fmt.Sprintf("MemoryMax=%d", limits.Memory))
+		}
+		path, err := exec.LookPath("systemd-run")
+		if err != nil {
+			return err
+		}
+		LogDebug("Limiting resources with %s", strings.Join(args, " "))
+		cmd.Path = path
+		cmd.Args = append(append(args, "--"), cmd.Args...)
+		return nil
+	}
+
+	if limits.CPUPercent > 0 {
+		PrintWarning("CPU limit needs cgroup v2 and a systemd user session, running without it")
+	}
+	if limits.Memory > 0 {
+		sh, err := exec.LookPath("sh")
+		if err != nil {
+			return err
+		}
+		script := fmt.Sprintf(`ulimit -v %d && exec "$@"`, limits.Memory/1024)
+		LogDebug("Limiting memory with: %s", script)
+		cmd.Path = sh
+		cmd.Args = append([]string{"sh", "-c", script, "agenter-limits"}, cmd.Args...)
+	}
+	return nil
+}
+
+// systemdScopeAvailable reports whether we can put a command in its own
+// cgroup v2 scope with systemd-run.
+func systemdScopeAvailable() bool {
+	if _, err := os.Stat("/sys/fs/cgroup/cgroup.controllers"); err != nil {
+		return false
+	}
+	if _, err := exec.LookPath("systemd-run"); err != nil {
+		return false
+	}
+	err := exec.Command("systemd-run", "--user", "--scope", "--quiet", "--collect", "true").Run()
+	if err != nil {
+		LogDebug("systemd-run --user is not usable: %v", err)
+	}
+	return err == nil
+}
+
+// timeoutError is returned when a session ran past its time box.
+type timeoutError struct {
+	after time.Duration
+}
+
+func (e *timeoutError) Error() string {
+	return fmt.Sprintf("timed out after %s", e.after)
+}
+
+// timeBoxOutcome checkpoints the work of a session that ran out of time
+// and describes what happened for the session log. Sessions that ended
+// on their own get "".
+func timeBoxOutcome(err error, dir string, agent string) string {
+	if _, ok := err.(*timeoutError); !ok {
+		return ""
+	}
+	PrintWarning("%s %v", agent, err)
+
+	ref, saveErr := checkpointWIP(dir, agent, err.Error())
+	switch {
+	case saveErr != nil:
+		PrintWarning("Could not checkpoint uncommitted work: %v", saveErr)
+		return fmt.Sprintf("%v, could not checkpoint uncommitted work: %v", err, saveErr)
+	case ref == "":
+		return fmt.Sprintf("%v, no uncommitted work", err)
+	}
+	PrintInfo("Uncommitted work saved to %s", ref)
+	PrintInfo("Restore it with: git checkout %s -- .", ref)
+	return fmt.Sprintf("%v, uncommitted work saved to %s", err, ref)
+}
+
+// processTree returns pid and all its descendants, children first so
+// they can be signalled before their parents notice. It relies on /proc
+// and returns just pid elsewhere.
+func processTree(pid int) []int {
+	children := make(map[int][]int)
+	entries, _ := os.ReadDir("/proc")
+	for _, entry := range entries {
+		child, err := strconv.Atoi(entry.Name())
+		if err != nil {
+			continue
+		}
+		stat, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		// The command name may contain spaces, so parse after its ")"
+		fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+		if len(fields) < 2 {
+			continue
+		}
+		if parent, err := strconv.Atoi(fields[1]); err == nil {
+			children[parent] = append(children[parent], child)
+		}
+	}
+
+	var tree []int
+	var walk func(int)
+	walk = func(p int) {
+		for _, c := range children[p] {
+			walk(c)
+		}
+		tree = append(tree, p)
+	}
+	walk(pid)
+	return tree
+}
+
+// signalProcesses sends sig to each of pids.
+func signalProcesses(pids []int, sig syscall.Signal) {
+	for _, pid := range pids {
+		syscall.Kill(pid, sig)
+	}
+}
+
+// checkpointWIP saves uncommitted changes in dir, including untracked
+// files, as a commit on refs/agenter/wip/<agent>/<time>. It works on a
+// scratch index so the worktree and the real index are left as they
+// are. It returns "" when there was nothing to save.
+func checkpointWIP(dir string, agent string, reason string) (string, error) {
+	gitDir, err := gitOutput(dir, "rev-parse", "--absolute-git-dir")
+	if err != nil {
+		return "", err
+	}
+	index, err := os.CreateTemp(gitDir, "agenter-wip-index-")
+	if err != nil {
+		return "", err
+	}
+	index.Close()
+	defer os.Remove(index.Name())
+
+	git := func(args ...string) (string, error) {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(), "GIT_INDEX_FILE="+index.Name())
+		output, err := cmd.Output()
+		if err != nil {
+			return "", fmt.Errorf("git %s: %v", args[0], err)
+		}
+		return strings.TrimSpace(string(output)), nil
+	}
+
+	head, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return "", err
+	}
+	if _, err := git("read-tree", "HEAD"); err != nil {
+		return "", err
+	}
+	if _, err := git("add", "-A"); err != nil {
+		return "", err
+	}
+	tree, err := git("write-tree")
+	if err != nil {
+		return "", err
+	}
+	if headTree, _ := git("rev-parse", "HEAD^{tree}"); tree == headTree {
+		return "", nil
+	}
+
+	commit, err := git("commit-tree", tree, "-p", head, "-m", fmt.Sprintf("WIP: %s (%s)", agent, reason))
+	if err != nil {
+		return "", err
+	}
+	// An empty old value makes update-ref refuse to replace a checkpoint
+	// from the same second
+	base := fmt.Sprintf("refs/agenter/wip/%s/%s", agent, time.Now().Format(sessionIDLayout))
+	ref := base
+	for i := 2; ; i++ {
+		if _, err = git("update-ref", ref, commit, ""); err == nil {
+			return ref, nil
+		}
+		if i > 100 {
+			return "", err
+		}
+		ref = fmt.Sprintf("%s-%d", base, i)
+	}
+}
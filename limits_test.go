@@ -0,0 +1,227 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestLimitsConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		project LimitsConfig
+		agent   LimitsConfig
+		want    resourceLimits
+		wantErr bool
+	}{
+		{"none", LimitsConfig{}, LimitsConfig{}, resourceLimits{Grace: defaultGrace}, false},
+		{
+			"project",
+			LimitsConfig{Timeout: "2h", Grace: "10s", CPU: "150%", Memory: "4G"},
+			LimitsConfig{},
+			resourceLimits{Timeout: 2 * time.Hour, Grace: 10 * time.Second, CPUPercent: 150, Memory: 4 << 30},
+			false,
+		},
+		{
+			"agent overrides",
+			LimitsConfig{Timeout: "2h", Memory: "4G"},
+			LimitsConfig{Timeout: "30m", CPU: "0.5"},
+			resourceLimits{Timeout: 30 * time.Minute, Grace: defaultGrace, CPUPercent: 50, Memory: 4 << 30},
+			false,
+		},
+		{"bad timeout", LimitsConfig{Timeout: "soon"}, LimitsConfig{}, resourceLimits{}, true},
+		{"negative timeout", LimitsConfig{Timeout: "-1h"}, LimitsConfig{}, resourceLimits{}, true},
+		{"bad cpu", LimitsConfig{CPU: "lots%"}, LimitsConfig{}, resourceLimits{}, true},
+		{"bad memory", LimitsConfig{}, LimitsConfig{Memory: "4X"}, resourceLimits{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &ProjectConfig{Limits: tt.project}
+			got, err := cfg.LimitsFor(&AgentConfig{Name: "forge", Limits: tt.agent})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %+v", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := ParseProjectConfig([]byte("limits:\n  memory: lots\nagents:\n  - name: forge\n")); err == nil {
+		t.Error("expected invalid limits to be rejected")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"1024", 1024},
+		{"512K", 512 << 10},
+		{"512m", 512 << 20},
+		{"4G", 4 << 30},
+		{"1T", 1 << 40},
+		{"", 0},
+		{"G", 0},
+		{"-1G", 0},
+	}
+	for _, tt := range tests {
+		got, err := parseSize(tt.in)
+		if tt.want == 0 {
+			if err == nil {
+				t.Errorf("parseSize(%q) = %d, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("parseSize(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestProcessTree(t *testing.T) {
+	cmd := exec.Command("sh", "-c", "sleep 30 & wait")
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Wait()
+	defer func() { signalProcesses(processTree(cmd.Process.Pid), syscall.SIGKILL) }()
+
+	var tree []int
+	for i := 0; i < 50 && len(tree) < 2; i++ {
+		time.Sleep(20 * time.Millisecond)
+		tree = processTree(cmd.Process.Pid)
+	}
+	if len(tree) != 2 || tree[1] != cmd.Process.Pid {
+		t.Errorf("tree = %v, want the sleep then %d", tree, cmd.Process.Pid)
+	}
+}
+
+func TestCheckpointWIP(t *testing.T) {
+	repo := newTestRepo(t)
+
+	// Nothing to save in a clean worktree
+	if ref, err := checkpointWIP(repo, "forge", "test"); err != nil || ref != "" {
+		t.Fatalf("clean worktree gave %q, %v", ref, err)
+	}
+
+	os.WriteFile(filepath.Join(repo, "README.md"), []byte("changed\n"), 0644)
+	os.WriteFile(filepath.Join(repo, "notes.txt"), []byte("untracked\n"), 0644)
+	before := runTestGit(t, repo, "status", "--porcelain")
+
+	ref, err := checkpointWIP(repo, "forge", "timed out after 2h")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(ref, "refs/agenter/wip/forge/") {
+		t.Fatalf("ref = %q", ref)
+	}
+	if got := strings.TrimSpace(runTestGit(t, repo, "show", ref+":notes.txt")); got != "untracked" {
+		t.Errorf("untracked file saved as %q", got)
+	}
+	if got := strings.TrimSpace(runTestGit(t, repo, "show", ref+":README.md")); got != "changed" {
+		t.Errorf("modified file saved as %q", got)
+	}
+	if got := strings.TrimSpace(runTestGit(t, repo, "log", "-1", "--format=%s", ref)); got != "WIP: forge (timed out after 2h)" {
+		t.Errorf("message = %q", got)
+	}
+	if got := strings.TrimSpace(runTestGit(t, repo, "rev-parse", ref+"^")); got != strings.TrimSpace(runTestGit(t, repo, "rev-parse", "HEAD")) {
+		t.Errorf("checkpoint parent = %s, want HEAD", got)
+	}
+
+	// The worktree and index are untouched
+	if after := runTestGit(t, repo, "status", "--porcelain"); after != before {
+		t.Errorf("status changed from:\n%s\nto:\n%s", before, after)
+	}
+
+	// A second checkpoint in the same second doesn't replace the first
+	again, err := checkpointWIP(repo, "forge", "again")
+	if err != nil || again == ref {
+		t.Errorf("second checkpoint gave %q, %v", again, err)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	os.WriteFile(filepath.Join(repo, ProjectConfigFile), []byte(`
+limits:
+  grace: 200ms
+agents:
+  - name: forge
+  - name: axiom
+    limits:
+      timeout: 1h
+`), 0644)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	home := os.Getenv("HOME")
+
+	tests := []struct {
+		name   string
+		script string
+	}{
+		// Wraps up when interrupted
+		{"interrupted", "trap 'echo interrupted > \"$HOME/interrupted\"; exit 130' INT\nsleep 30 & wait\n"},
+		// Ignores the interrupt and has to be killed
+		{"killed", "trap '' INT\nwhile :; do sleep 1; done\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := filepath.Join(t.TempDir(), "fake-claude")
+			script := "#!/bin/sh\ncat > /dev/null\necho unsaved > wip.txt\n" + tt.script
+			os.WriteFile(fake, []byte(script), 0755)
+			t.Setenv("AGENTER_CLAUDE", fake)
+			os.Chdir(repo)
+
+			// --timeout beats the agent's own limit
+			start := time.Now()
+			err := runRunImpl("axiom", runOptions{Prompt: "work", Timeout: 300 * time.Millisecond})
+			if code := exitCode(err); code != 124 {
+				t.Fatalf("exit code = %d (%v), want 124", code, err)
+			}
+			if elapsed := time.Since(start); elapsed > 10*time.Second {
+				t.Errorf("took %s to stop", elapsed)
+			}
+
+			worktree := CurrentConfig().WorktreePath("axiom")
+			refs := strings.TrimSpace(runTestGit(t, worktree, "for-each-ref", "--format=%(refname)", "refs/agenter/wip/axiom/"))
+			if refs == "" {
+				t.Fatal("no WIP ref created")
+			}
+			ref := strings.Split(refs, "\n")[0]
+			if got := strings.TrimSpace(runTestGit(t, worktree, "show", ref+":wip.txt")); got != "unsaved" {
+				t.Errorf("WIP ref has wip.txt = %q", got)
+			}
+			runTestGit(t, worktree, "update-ref", "-d", ref)
+
+			sessions, _ := listSessions(CurrentConfig(), "axiom")
+			if len(sessions) == 0 || sessions[0].Exit != "timeout" {
+				t.Fatalf("sessions = %+v", sessions)
+			}
+			log, _ := os.ReadFile(sessions[0].Path)
+			if !strings.Contains(string(log), "# agenter: timed out after 300ms, uncommitted work saved to "+ref) {
+				t.Errorf("session log missing outcome:\n%s", log)
+			}
+		})
+	}
+
+	if _, err := os.Stat(filepath.Join(home, "interrupted")); err != nil {
+		t.Error("agent never saw the interrupt")
+	}
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// agentLockFile lives in the worktree's private git dir, so it never
+// shows up in 'git status' and goes away with the worktree.
+const agentLockFile = "agenter.lock"
+
+// agentLock records the agenter process running an agent in a worktree.
+type agentLock struct {
+	PID     int       `json:"pid"`
+	TTY     string    `json:"tty,omitempty"`
+	Agent   string    `json:"agent"`
+	Host    string    `json:"host"`
+	Started time.Time `json:"started"`
+
+	path string
+}
+
+// agentLockPath returns the lock file location for the worktree at dir.
+func agentLockPath(dir string) (string, error) {
+	gitDir, err := gitOutput(dir, "rev-parse", "--git-dir")
+	if err != nil {
+		return "", fmt.Errorf("could not find git dir for %s: %v", dir, err)
+	}
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(dir, gitDir)
+	}
+	return filepath.Join(gitDir, agentLockFile), nil
+}
+
+// readAgentLock returns the lock held on the worktree at dir, or nil
+// when there is none.
+func readAgentLock(dir string) (*agentLock, error) {
+	path, err := agentLockPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	lock := &agentLock{path: path}
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("corrupt lock file %s: %v", path, err)
+	}
+	return lock, nil
+}
+
+// Alive reports whether the process holding the lock is still running.
+// Locks taken on another host (shared filesystems) are assumed alive
+// since we can't check.
+func (l *agentLock) Alive() bool {
+	if host, _ := os.Hostname(); l.Host != "" && l.Host != host {
+		return true
+	}
+	return processAlive(l.PID)
+}
+
+// Describe summarizes who holds the lock, e.g. "pid 42 on /dev/pts/3".
+func (l *agentLock) Describe() string {
+	desc := fmt.Sprintf("pid %d", l.PID)
+	if l.TTY != "" {
+		desc += " on " + l.TTY
+	}
+	if host, _ := os.Hostname(); l.Host != "" && l.Host != host {
+		desc += " at " + l.Host
+	}
+	return desc
+}
+
+// Release removes the lock if it's still ours.
+func (l *agentLock) Release() error {
+	data, err := os.ReadFile(l.path)
+	if err != nil {
+		return nil
+	}
+	var current agentLock
+	if json.Unmarshal(data, &current) == nil && current.PID != l.PID {
+		return nil
+	}
+	return os.Remove(l.path)
+}
+
+// acquireAgentLock makes sure only one agenter runs agent in the worktree
+// at dir. A lock left behind by a crashed session is cleaned up.
+func acquireAgentLock(dir string, agent string) (*agentLock, error) {
+	path, err := agentLockPath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _ := os.Hostname()
+	lock := &agentLock{
+		PID:     os.Getpid(),
+		TTY:     ttyName(),
+		Agent:   agent,
+		Host:    host,
+		Started: time.Now().Truncate(time.Second),
+		path:    path,
+	}
+	data, err := json.Marshal(lock)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, err = f.Write(data)
+			if closeErr := f.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, err
+			}
+			return lock, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		existing, err := readAgentLock(dir)
+		if err == nil && existing != nil && existing.Alive() {
+			return nil, fmt.Errorf("%s is already running in %s (%s, started %s)",
+				existing.Agent, FormatPath(dir), existing.Describe(), FormatAge(existing.Started))
+		}
+		if existing != nil {
+			PrintWarning("Removing stale lock from a crashed session (%s)", existing.Describe())
+		} else {
+			PrintWarning("Removing unreadable lock file %s", FormatPath(path))
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("could not lock %s", FormatPath(dir))
+}
+
+// processAlive reports whether pid is a running process. EPERM means it
+// exists but belongs to someone else.
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := syscall.Kill(pid, 0)
+	return err == nil || err == syscall.EPERM
+}
+
+// ttyName returns the terminal on our stdin, or "" if there isn't one.
+func ttyName() string {
+	cmd := exec.Command("tty")
+	cmd.Stdin = os.Stdin
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(output))
+}
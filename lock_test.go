@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// deadPID returns the PID of a process that has already exited.
+func deadPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command("true")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestAgentLockRefusesSecondLaunch(t *testing.T) {
+	repo := newTestRepo(t)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	forge := CurrentConfig().WorktreePath("forge")
+
+	lock, err := acquireAgentLock(forge, "forge")
+	if err != nil {
+		t.Fatalf("first lock failed: %v", err)
+	}
+
+	// The lock lives in the worktree's own git dir, not the checkout
+	if !strings.Contains(lock.path, "/.git/worktrees/") {
+		t.Errorf("lock stored at %s", lock.path)
+	}
+
+	_, err = acquireAgentLock(forge, "forge")
+	if err == nil {
+		t.Fatal("second lock should fail")
+	}
+	if !strings.Contains(err.Error(), "already running") || !strings.Contains(err.Error(), "pid") {
+		t.Errorf("unhelpful error: %v", err)
+	}
+
+	// Other agents are unaffected
+	axiomLock, err := acquireAgentLock(CurrentConfig().WorktreePath("axiom"), "axiom")
+	if err != nil {
+		t.Fatalf("axiom lock failed: %v", err)
+	}
+	axiomLock.Release()
+
+	status := collectAgentStatus(CurrentConfig(), CurrentConfig().Agent("forge"))
+	if status.Lock == nil || status.Lock.PID != os.Getpid() {
+		t.Errorf("status lock = %+v", status.Lock)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if current, _ := readAgentLock(forge); current != nil {
+		t.Errorf("lock still held after release: %+v", current)
+	}
+}
+
+func TestAgentLockReplacesStaleLock(t *testing.T) {
+	repo := newTestRepo(t)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	forge := CurrentConfig().WorktreePath("forge")
+
+	host, _ := os.Hostname()
+	stale := agentLock{PID: deadPID(t), Agent: "forge", Host: host, Started: time.Now()}
+	data, _ := json.Marshal(stale)
+	path, _ := agentLockPath(forge)
+	os.WriteFile(path, data, 0644)
+
+	status := collectAgentStatus(CurrentConfig(), CurrentConfig().Agent("forge"))
+	if status.Lock != nil || len(status.Warnings) == 0 {
+		t.Errorf("stale lock should be reported as a warning, got lock %+v warnings %v", status.Lock, status.Warnings)
+	}
+
+	lock, err := acquireAgentLock(forge, "forge")
+	if err != nil {
+		t.Fatalf("stale lock was not replaced: %v", err)
+	}
+	defer lock.Release()
+	if current, _ := readAgentLock(forge); current == nil || current.PID != os.Getpid() {
+		t.Errorf("lock = %+v, want ours", current)
+	}
+}
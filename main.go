@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
 )
@@ -10,6 +11,50 @@ import (
 var (
 	verbose bool
 	debug   bool
+
+	listJSON  bool
+	listPrune bool
+
+	launchAll      bool
+	launchProject  string
+	launchNoRecord bool
+	launchSandbox  bool
+	launchTimeout  time.Duration
+
+	runProject    string
+	runPromptFile string
+	runTopic      string
+	runIssue      string
+	runPush       bool
+	runSandbox    bool
+	runTimeout    time.Duration
+
+	nextStrategy string
+	nextStash    bool
+	nextWIP      bool
+	topicIssue   string
+
+	pushPR        bool
+	pushDraft     bool
+	pushLabels    []string
+	pushReviewers []string
+
+	teardownForce bool
+
+	topicsJSON bool
+
+	pruneDryRun  bool
+	pruneYes     bool
+	syncStrategy string
+
+	superviseMaxRestarts int
+	superviseBackoff     time.Duration
+	superviseMaxBackoff  time.Duration
+
+	sessionsProjectFlag string
+	sessionsPlain       bool
+	upDetach            bool
+	upPanes             bool
 )
 
 var rootCmd = &cobra.Command{
@@ -17,6 +62,49 @@ var rootCmd = &cobra.Command{
 	Short:   "Multi-agent orchestration for Claude",
 	Long:    "Agenter helps you run multiple Claude Code instances in parallel with isolated contexts using git worktrees.",
 	Version: Version,
+	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		InitLogger(debug)
+		cwd, err := os.Getwd()
+		if err != nil {
+			PrintError("Could not get current directory: %v", err)
+			os.Exit(1)
+		}
+		if err := InitConfig(cwd); err != nil {
+			// One broken config shouldn't lock users out of commands
+			// that don't need this project's roster
+			if needsCurrentProject(cmd, args) {
+				PrintError("Could not load project config: %v", err)
+				os.Exit(1)
+			}
+			PrintWarning("Could not load project config, using default agents: %v", err)
+			activeConfig = DefaultProjectConfig()
+		}
+	},
+}
+
+// Commands annotated with these work on the roster of the project we're
+// in, so they can't run when its config is broken. usesArgProject is
+// for commands that take another project as an argument.
+var (
+	usesCurrentProject = map[string]string{"project": "current"}
+	usesArgProject     = map[string]string{"project": "current unless given"}
+)
+
+// needsCurrentProject reports whether cmd, run with args, works on the
+// current project rather than a global list or one named by --project.
+func needsCurrentProject(cmd *cobra.Command, args []string) bool {
+	if flag := cmd.Flags().Lookup("project"); flag != nil && flag.Changed {
+		return false
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c.Annotations["project"] {
+		case usesCurrentProject["project"]:
+			return true
+		case usesArgProject["project"]:
+			return len(args) == 0
+		}
+	}
+	return false
 }
 
 var initCmd = &cobra.Command{
@@ -36,29 +124,107 @@ var checkCmd = &cobra.Command{
 var setupCmd = &cobra.Command{
 	Use:   "setup <repository>",
 	Short: "Create worktrees for a repository",
-	Long:  "Set up a repository with agent worktrees for Forge, Axiom, and Jarvis.",
+	Long:  "Set up a repository with a worktree for each agent in its roster (agenter.yaml, or Forge, Axiom, and Jarvis by default).",
 	Args:  cobra.ExactArgs(1),
 	Run:   runSetup,
 }
 
+var teardownCmd = &cobra.Command{
+	Use:         "teardown [repository]",
+	Short:       "Remove agent worktrees and branches",
+	Long:        "Undo setup for a repository (the current one by default, or a project name or path): remove every agent's worktree, base branch and topic branches, prune worktree metadata and forget the project. Nothing is removed while any agent has uncommitted changes, unpushed commits or a running session, unless --force is given.",
+	Args:        cobra.MaximumNArgs(1),
+	Run:         runTeardown,
+	Annotations: usesArgProject,
+}
+
 var launchCmd = &cobra.Command{
-	Use:   "launch <agent>",
-	Short: "Launch agent with sandboxing",
-	Long:  "Launch Claude Code as a specific agent with sandbox.",
-	Args:  cobra.ExactArgs(1),
-	Run:   runLaunch,
+	Use:         "launch <agent> [-- tool args...]",
+	Short:       "Launch agent in its worktree",
+	Long:        "Launch Claude Code (or the agent's configured backend) as a specific agent in its worktree. Run it from the agent's worktree, anywhere else in the project, or anywhere at all with --project. Arguments after -- are passed to the tool. With --all, launch every agent in a tmux session. On Linux, --sandbox makes everything next to the worktree read-only using bubblewrap or Landlock. With --timeout, the agent is interrupted when time is up and its uncommitted work saved to a WIP ref.",
+	Args:        cobra.ArbitraryArgs,
+	Run:         runLaunch,
+	Annotations: usesCurrentProject,
+}
+
+var runCmd = &cobra.Command{
+	Use:         "run <agent> [prompt] [-- tool args...]",
+	Short:       "Run an agent headless on one prompt",
+	Long:        "Run the agent's tool non-interactively in its worktree, with a prompt or --prompt-file. Output is streamed to the terminal and a session log, and agenter exits with the tool's exit code. Use --topic to work on a new topic branch and --push to push it when the run succeeds. A run that hits its --timeout exits with 124 after saving uncommitted work to a WIP ref.",
+	Args:        cobra.ArbitraryArgs,
+	Run:         runRun,
+	Annotations: usesCurrentProject,
+}
+
+var syncCmd = &cobra.Command{
+	Use:         "sync",
+	Short:       "Update every agent's base branch from the integration branch",
+	Long:        "Fetch the integration branch once, then bring every agent's base branch up to date with it in parallel. Agents on a topic branch or with uncommitted changes are skipped, and an update that conflicts is backed out and reported. --strategy picks merge, rebase or reset, overriding update_strategy in the config.",
+	Run:         runSync,
+	Annotations: usesCurrentProject,
+}
+
+var superviseCmd = &cobra.Command{
+	Use:         "supervise <agent> [prompt] [-- tool args...]",
+	Short:       "Keep a headless agent running, restarting it when it fails",
+	Long:        "Run the agent's tool headless like 'agenter run', restarting it with exponential backoff whenever it fails, up to --max-restarts times. SIGINT and SIGTERM are passed to the tool and end supervision, and agenter exits with the tool's exit code. The supervisor's state shows in 'agenter status'.",
+	Args:        cobra.ArbitraryArgs,
+	Run:         runSupervise,
+	Annotations: usesCurrentProject,
+}
+
+var upCmd = &cobra.Command{
+	Use:         "up",
+	Short:       "Launch all agents in tmux",
+	Long:        "Start a tmux session for the project with one window per agent, each running in its own worktree.",
+	Args:        cobra.NoArgs,
+	Run:         runUp,
+	Annotations: usesCurrentProject,
+}
+
+var downCmd = &cobra.Command{
+	Use:         "down",
+	Short:       "Stop the tmux session",
+	Long:        "Stop the project's tmux session started by 'agenter up', ending every agent in it.",
+	Args:        cobra.NoArgs,
+	Run:         runDown,
+	Annotations: usesCurrentProject,
+}
+
+var sessionsCmd = &cobra.Command{
+	Use:         "sessions",
+	Short:       "Browse recorded sessions",
+	Long:        "Browse the session transcripts recorded by 'agenter launch' in ~/.agenter/sessions.",
+	Annotations: usesCurrentProject,
+}
+
+var sessionsListCmd = &cobra.Command{
+	Use:   "list [agent]",
+	Short: "List recorded sessions",
+	Long:  "List recorded sessions for one agent, or every agent in the project, newest first.",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runSessionsList,
+}
+
+var sessionsShowCmd = &cobra.Command{
+	Use:   "show <agent> [id]",
+	Short: "Print a recorded session",
+	Long:  "Print a recorded session with its metadata header. Shows the agent's latest session unless an id is given.",
+	Args:  cobra.RangeArgs(1, 2),
+	Run:   runSessionsShow,
 }
 
 var worktreeCmd = &cobra.Command{
-	Use:   "worktree",
-	Short: "Git worktree management",
-	Long:  "Manage git worktrees for agent development workflows.",
+	Use:         "worktree",
+	Short:       "Git worktree management",
+	Long:        "Manage git worktrees for agent development workflows.",
+	Annotations: usesCurrentProject,
 }
 
 var worktreeMakeCmd = &cobra.Command{
 	Use:   "make <topic>",
 	Short: "Create topic branch",
-	Long:  "Create a new topic branch from the current agent's base branch.",
+	Long:  "Create a new topic branch from the current agent's base branch, named by branch_template (<base branch>-<topic> by default). Topics that aren't valid in a branch name can be slugified.",
 	Args:  cobra.ExactArgs(1),
 	Run:   runWorktreeMake,
 }
@@ -66,18 +232,42 @@ var worktreeMakeCmd = &cobra.Command{
 var worktreePushCmd = &cobra.Command{
 	Use:   "push",
 	Short: "Push topic, get PR URL",
-	Long:  "Push the current topic branch and display the PR creation URL.",
+	Long:  "Push the current topic branch and display the PR creation URL. With --pr, open the pull request with gh instead, titled and described from pull_request in the config and the branch's commits; pushing again updates it.",
 	Run:   runWorktreePush,
 }
 
 var worktreeNextCmd = &cobra.Command{
 	Use:   "next [topic]",
 	Short: "Return to base, start new topic",
-	Long:  "Return to the agent's base branch and optionally start a new topic.",
+	Long:  "Return to the agent's base branch, update it from the integration branch (main_branch, or origin/HEAD) and optionally start a new topic, or resume it if it already exists. --strategy picks merge, rebase or reset, overriding update_strategy in the config. --stash or --wip save uncommitted changes on the topic for 'worktree resume'.",
 	Args:  cobra.MaximumNArgs(1),
 	Run:   runWorktreeNext,
 }
 
+var worktreeResumeCmd = &cobra.Command{
+	Use:   "resume <topic>",
+	Short: "Return to a topic and restore saved work",
+	Long:  "Check out an existing topic branch and restore the work 'worktree next --stash' or '--wip' saved from it.",
+	Args:  cobra.ExactArgs(1),
+	Run:   runWorktreeResume,
+}
+
+var worktreePruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete merged topic branches",
+	Long:  "Find every agent's topic branches that are merged into the integration branch, including squash merges (matched by patch id, or by the pull request's state when gh is installed), and delete them locally and on origin after confirmation.",
+	Args:  cobra.NoArgs,
+	Run:   runWorktreePrune,
+}
+
+var worktreeTopicsCmd = &cobra.Command{
+	Use:   "topics",
+	Short: "List every agent's topic branches",
+	Long:  "List every local and origin branch matching the branch template (<agent>-worktree-<topic> by default) with commits ahead of and behind the integration branch, the last commit time, whether it is pushed and its pull request (via gh, when installed).",
+	Args:  cobra.NoArgs,
+	Run:   runWorktreeTopics,
+}
+
 var worktreeListCmd = &cobra.Command{
 	Use:   "list",
 	Short: "List agent worktrees",
@@ -88,22 +278,33 @@ var worktreeListCmd = &cobra.Command{
 var worktreeCreateCmd = &cobra.Command{
 	Use:   "create",
 	Short: "Create agent worktrees",
-	Long:  "Create worktrees for all agents (forge, axiom, jarvis) in the current repository.",
+	Long:  "Create worktrees for all configured agents in the current repository.",
 	Run:   runWorktreeCreate,
 }
 
 var listCmd = &cobra.Command{
 	Use:   "list",
 	Short: "Show configured projects",
-	Long:  "Display all projects configured with agenter.",
+	Long:  "Display all projects set up with agenter, as recorded in ~/.agenter/config.yaml.",
 	Run:   runList,
 }
 
+// sandboxExecCmd is re-executed by --sandbox to apply Landlock before
+// running the agent's tool. It skips the root command's config loading.
+var sandboxExecCmd = &cobra.Command{
+	Use:                "__sandbox-exec <command> [args...]",
+	Hidden:             true,
+	DisableFlagParsing: true,
+	PersistentPreRun:   func(cmd *cobra.Command, args []string) {},
+	Run:                runSandboxExec,
+}
+
 var statusCmd = &cobra.Command{
-	Use:   "status",
-	Short: "Health check for all agents",
-	Long:  "Check the status and health of all configured agents.",
-	Run:   runStatus,
+	Use:         "status",
+	Short:       "Health check for all agents",
+	Long:        "Check the status and health of all configured agents. Exits non-zero when any agent is unhealthy.",
+	Run:         runStatus,
+	Annotations: usesCurrentProject,
 }
 
 func init() {
@@ -115,14 +316,76 @@ func init() {
 	rootCmd.AddCommand(checkCmd)
 	rootCmd.AddCommand(setupCmd)
 	rootCmd.AddCommand(launchCmd)
+	rootCmd.AddCommand(teardownCmd)
+	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(superviseCmd)
+	rootCmd.AddCommand(syncCmd)
+	rootCmd.AddCommand(upCmd)
+	rootCmd.AddCommand(downCmd)
 	rootCmd.AddCommand(listCmd)
 	rootCmd.AddCommand(statusCmd)
+	rootCmd.AddCommand(sandboxExecCmd)
+
+	launchCmd.Flags().BoolVar(&launchAll, "all", false, "Launch every agent in a tmux session")
+	launchCmd.Flags().StringVarP(&launchProject, "project", "p", "", "Project name or path to launch the agent for")
+	launchCmd.Flags().BoolVar(&launchNoRecord, "no-record", false, "Don't record a session transcript")
+	launchCmd.Flags().DurationVar(&launchTimeout, "timeout", 0, "End the session after this long, e.g. 2h (overrides limits.timeout)")
+	for _, cmd := range []*cobra.Command{launchCmd, upCmd} {
+		cmd.Flags().BoolVar(&launchSandbox, "sandbox", false, "Make everything but the agent's worktree read-only (Linux)")
+		cmd.Flags().BoolVar(&upDetach, "detach", false, "Start the tmux session without attaching")
+		cmd.Flags().BoolVar(&upPanes, "panes", false, "Use one pane per agent instead of one window")
+	}
+
+	runCmd.Flags().StringVarP(&runProject, "project", "p", "", "Project name or path to run the agent for")
+	runCmd.Flags().StringVarP(&runPromptFile, "prompt-file", "f", "", "Read the prompt from a file (- for stdin)")
+	runCmd.Flags().StringVar(&runTopic, "topic", "", "Create a topic branch before running")
+	runCmd.Flags().StringVar(&runIssue, "issue", "", "Issue for the topic branch, if branch_template has {issue}")
+	runCmd.Flags().BoolVar(&runPush, "push", false, "Push the topic branch if the run succeeds")
+	runCmd.Flags().BoolVar(&runSandbox, "sandbox", false, "Make everything but the agent's worktree read-only (Linux)")
+	runCmd.Flags().DurationVar(&runTimeout, "timeout", 0, "End the run after this long, e.g. 30m (overrides limits.timeout)")
+
+	superviseCmd.Flags().StringVarP(&runProject, "project", "p", "", "Project name or path to run the agent for")
+	superviseCmd.Flags().StringVarP(&runPromptFile, "prompt-file", "f", "", "Read the prompt from a file (- for stdin)")
+	superviseCmd.Flags().BoolVar(&runSandbox, "sandbox", false, "Make everything but the agent's worktree read-only (Linux)")
+	superviseCmd.Flags().DurationVar(&runTimeout, "timeout", 0, "End each run after this long (overrides limits.timeout)")
+	superviseCmd.Flags().IntVar(&superviseMaxRestarts, "max-restarts", 5, "Give up after this many restarts (-1 for no limit)")
+	superviseCmd.Flags().DurationVar(&superviseBackoff, "backoff", time.Second, "Wait before the first restart, doubling after each failure")
+	superviseCmd.Flags().DurationVar(&superviseMaxBackoff, "max-backoff", 5*time.Minute, "Longest wait between restarts")
+
+	teardownCmd.Flags().BoolVar(&teardownForce, "force", false, "Remove agents even if work would be lost")
+
+	listCmd.Flags().BoolVar(&listJSON, "json", false, "Print projects as JSON")
+	listCmd.Flags().BoolVar(&listPrune, "prune", false, "Remove projects whose paths no longer exist")
+
+	// Add sessions subcommands
+	sessionsCmd.PersistentFlags().StringVarP(&sessionsProjectFlag, "project", "p", "", "Project name or path")
+	sessionsShowCmd.Flags().BoolVar(&sessionsPlain, "plain", false, "Strip terminal escape sequences")
+	sessionsCmd.AddCommand(sessionsListCmd)
+	sessionsCmd.AddCommand(sessionsShowCmd)
+	rootCmd.AddCommand(sessionsCmd)
 
 	// Add worktree subcommands
 	worktreeCmd.AddCommand(worktreeMakeCmd)
 	worktreeCmd.AddCommand(worktreePushCmd)
+	worktreePushCmd.Flags().BoolVar(&pushPR, "pr", false, "Open or update the pull request (GitHub, via gh)")
+	worktreePushCmd.Flags().BoolVar(&pushDraft, "draft", false, "Open the pull request as a draft")
+	worktreePushCmd.Flags().StringSliceVar(&pushLabels, "label", nil, "Add a label to the pull request (repeatable)")
+	worktreePushCmd.Flags().StringSliceVar(&pushReviewers, "reviewer", nil, "Request a review (repeatable)")
 	worktreeCmd.AddCommand(worktreeNextCmd)
+	for _, cmd := range []*cobra.Command{worktreeMakeCmd, worktreeNextCmd} {
+		cmd.Flags().StringVar(&topicIssue, "issue", "", "Issue for the topic branch, if branch_template has {issue}")
+	}
+	worktreeNextCmd.Flags().BoolVar(&nextStash, "stash", false, "Stash uncommitted changes for 'worktree resume'")
+	worktreeNextCmd.Flags().BoolVar(&nextWIP, "wip", false, "Commit uncommitted changes as WIP for 'worktree resume'")
+	worktreeCmd.AddCommand(worktreeResumeCmd)
+	worktreeNextCmd.Flags().StringVar(&nextStrategy, "strategy", "", "How to update the base branch: merge, rebase or reset")
+	syncCmd.Flags().StringVar(&syncStrategy, "strategy", "", "How to update base branches: merge, rebase or reset")
 	worktreeCmd.AddCommand(worktreeListCmd)
+	worktreeCmd.AddCommand(worktreeTopicsCmd)
+	worktreeTopicsCmd.Flags().BoolVar(&topicsJSON, "json", false, "Print topics as JSON")
+	worktreeCmd.AddCommand(worktreePruneCmd)
+	worktreePruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Only list the merged branches")
+	worktreePruneCmd.Flags().BoolVarP(&pruneYes, "yes", "y", false, "Delete without asking")
 	worktreeCmd.AddCommand(worktreeCreateCmd)
 	rootCmd.AddCommand(worktreeCmd)
 }
@@ -157,17 +420,164 @@ func runSetup(cmd *cobra.Command, args []string) {
 	}
 }
 
+func runTeardown(cmd *cobra.Command, args []string) {
+	InitLogger(debug)
+	repo := "."
+	if len(args) == 1 {
+		repo = args[0]
+	}
+	if err := runTeardownImpl(repo, teardownForce); err != nil {
+		PrintError("Teardown failed: %v", err)
+		os.Exit(1)
+	}
+}
+
 func runLaunch(cmd *cobra.Command, args []string) {
 	InitLogger(debug)
-	if err := runLaunchImpl(args[0]); err != nil {
+	// Everything after -- goes to the agent's tool untouched
+	var toolArgs []string
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		args, toolArgs = args[:dash], args[dash:]
+	}
+
+	if launchAll {
+		if len(args) > 0 || len(toolArgs) > 0 {
+			PrintError("Launch failed: --all doesn't take an agent name or tool arguments")
+			os.Exit(1)
+		}
+		runUp(cmd, args)
+		return
+	}
+	if len(args) != 1 {
+		PrintError("Launch failed: specify an agent, or --all to launch every agent")
+		os.Exit(1)
+	}
+	opts := launchOptions{
+		Project:  launchProject,
+		Args:     toolArgs,
+		NoRecord: launchNoRecord,
+		Sandbox:  launchSandbox,
+		Timeout:  launchTimeout,
+	}
+	if err := runLaunchImpl(args[0], opts); err != nil {
 		PrintError("Launch failed: %v", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func runRun(cmd *cobra.Command, args []string) {
+	InitLogger(debug)
+	var toolArgs []string
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		args, toolArgs = args[:dash], args[dash:]
+	}
+	if len(args) < 1 || len(args) > 2 {
+		PrintError("Run failed: specify an agent and a prompt")
+		os.Exit(1)
+	}
+
+	opts := runOptions{
+		Project:    runProject,
+		PromptFile: runPromptFile,
+		Topic:      runTopic,
+		Issue:      runIssue,
+		Push:       runPush,
+		Args:       toolArgs,
+		Sandbox:    runSandbox,
+		Timeout:    runTimeout,
+	}
+	if len(args) == 2 {
+		opts.Prompt = args[1]
+	}
+	if err := runRunImpl(args[0], opts); err != nil {
+		PrintError("Run failed: %v", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func runSupervise(cmd *cobra.Command, args []string) {
+	InitLogger(debug)
+	var toolArgs []string
+	if dash := cmd.ArgsLenAtDash(); dash >= 0 {
+		args, toolArgs = args[:dash], args[dash:]
+	}
+	if len(args) < 1 || len(args) > 2 {
+		PrintError("Supervise failed: specify an agent and a prompt")
+		os.Exit(1)
+	}
+
+	opts := superviseOptions{
+		runOptions: runOptions{
+			Project:    runProject,
+			PromptFile: runPromptFile,
+			Args:       toolArgs,
+			Sandbox:    runSandbox,
+			Timeout:    runTimeout,
+		},
+		MaxRestarts: superviseMaxRestarts,
+		Backoff:     superviseBackoff,
+		MaxBackoff:  superviseMaxBackoff,
+	}
+	if len(args) == 2 {
+		opts.Prompt = args[1]
+	}
+	if err := runSuperviseImpl(args[0], opts); err != nil {
+		PrintError("Supervise failed: %v", err)
+		os.Exit(exitCode(err))
+	}
+}
+
+func runSync(cmd *cobra.Command, args []string) {
+	InitLogger(debug)
+	if err := runSyncImpl(syncStrategy); err != nil {
+		PrintError("Sync failed: %v", err)
+		os.Exit(1)
+	}
+}
+
+func runUp(cmd *cobra.Command, args []string) {
+	InitLogger(debug)
+	if err := runUpImpl(!upDetach, upPanes, launchSandbox); err != nil {
+		PrintError("Failed to start agents: %v", err)
+		os.Exit(1)
+	}
+}
+
+func runDown(cmd *cobra.Command, args []string) {
+	InitLogger(debug)
+	if err := runDownImpl(); err != nil {
+		PrintError("Failed to stop agents: %v", err)
+		os.Exit(1)
+	}
+}
+
+func runSessionsList(cmd *cobra.Command, args []string) {
+	InitLogger(debug)
+	agent := ""
+	if len(args) > 0 {
+		agent = args[0]
+	}
+	if err := runSessionsListImpl(agent, sessionsProjectFlag); err != nil {
+		PrintError("Failed to list sessions: %v", err)
+		os.Exit(1)
+	}
+}
+
+func runSessionsShow(cmd *cobra.Command, args []string) {
+	InitLogger(debug)
+	id := ""
+	if len(args) > 1 {
+		id = args[1]
+	}
+	if err := runSessionsShowImpl(args[0], id, sessionsProjectFlag, sessionsPlain); err != nil {
+		PrintError("Failed to show session: %v", err)
 		os.Exit(1)
 	}
 }
 
 func runWorktreeMake(cmd *cobra.Command, args []string) {
 	InitLogger(debug)
-	if err := runWorktreeMakeImpl(args[0]); err != nil {
+	if err := runWorktreeMakeImpl(args[0], topicIssue); err != nil {
 		PrintError("Failed to create topic: %v", err)
 		os.Exit(1)
 	}
@@ -175,7 +585,12 @@ func runWorktreeMake(cmd *cobra.Command, args []string) {
 
 func runWorktreePush(cmd *cobra.Command, args []string) {
 	InitLogger(debug)
-	if err := runWorktreePushImpl(); err != nil {
+	opts := pushOptions{PR: pushPR, Draft: pushDraft, Labels: pushLabels, Reviewers: pushReviewers}
+	if (opts.Draft || len(opts.Labels) > 0 || len(opts.Reviewers) > 0) && !opts.PR {
+		PrintError("--draft, --label and --reviewer need --pr")
+		os.Exit(1)
+	}
+	if err := runWorktreePushImpl(opts); err != nil {
 		PrintError("Failed to push: %v", err)
 		os.Exit(1)
 	}
@@ -187,12 +602,36 @@ func runWorktreeNext(cmd *cobra.Command, args []string) {
 	if len(args) > 0 {
 		newTopic = args[0]
 	}
-	if err := runWorktreeNextImpl(newTopic); err != nil {
+	if err := runWorktreeNextImpl(newTopic, nextOptions{Issue: topicIssue, Strategy: nextStrategy, Stash: nextStash, WIP: nextWIP}); err != nil {
 		PrintError("Failed: %v", err)
 		os.Exit(1)
 	}
 }
 
+func runWorktreeResume(cmd *cobra.Command, args []string) {
+	InitLogger(debug)
+	if err := runWorktreeResumeImpl(args[0]); err != nil {
+		PrintError("Failed to resume: %v", err)
+		os.Exit(1)
+	}
+}
+
+func runWorktreePrune(cmd *cobra.Command, args []string) {
+	InitLogger(debug)
+	if err := runWorktreePruneImpl(pruneDryRun, pruneYes); err != nil {
+		PrintError("Prune failed: %v", err)
+		os.Exit(1)
+	}
+}
+
+func runWorktreeTopics(cmd *cobra.Command, args []string) {
+	InitLogger(debug)
+	if err := runWorktreeTopicsImpl(topicsJSON); err != nil {
+		PrintError("Failed to list topics: %v", err)
+		os.Exit(1)
+	}
+}
+
 func runWorktreeList(cmd *cobra.Command, args []string) {
 	InitLogger(debug)
 	if err := runWorktreeListImpl(); err != nil {
@@ -211,12 +650,23 @@ func runWorktreeCreate(cmd *cobra.Command, args []string) {
 
 func runList(cmd *cobra.Command, args []string) {
 	InitLogger(debug)
-	PrintInfo("Project listing not yet implemented")
-	PrintInfo("This will show all projects configured with agenter")
+	if err := runListImpl(listJSON, listPrune); err != nil {
+		PrintError("Failed to list projects: %v", err)
+		os.Exit(1)
+	}
+}
+
+func runSandboxExec(cmd *cobra.Command, args []string) {
+	if err := runSandboxExecImpl(args); err != nil {
+		PrintError("Sandbox failed: %v", err)
+		os.Exit(1)
+	}
 }
 
 func runStatus(cmd *cobra.Command, args []string) {
 	InitLogger(debug)
-	PrintInfo("Status command not yet implemented")
-	PrintInfo("This will show health status of all agents")
+	if err := runStatusImpl(); err != nil {
+		PrintError("Status: %v", err)
+		os.Exit(1)
+	}
 }
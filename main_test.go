@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+func TestNeedsCurrentProject(t *testing.T) {
+	// A launch with --project works on that project instead
+	other := &cobra.Command{Use: "launch", Annotations: usesCurrentProject}
+	other.Flags().String("project", "", "")
+	other.Flags().Set("project", "elsewhere")
+
+	tests := []struct {
+		name string
+		cmd  *cobra.Command
+		args []string
+		want bool
+	}{
+		{"list", listCmd, nil, false},
+		{"setup", setupCmd, []string{"../other"}, false},
+		{"check", checkCmd, nil, false},
+		{"status", statusCmd, nil, true},
+		{"worktree make", worktreeMakeCmd, []string{"fix"}, true},
+		{"sessions list", sessionsListCmd, nil, true},
+		{"teardown here", teardownCmd, nil, true},
+		{"teardown other", teardownCmd, []string{"other"}, false},
+		{"launch --project", other, []string{"forge"}, false},
+	}
+
+	for _, tt := range tests {
+		if got := needsCurrentProject(tt.cmd, tt.args); got != tt.want {
+			t.Errorf("%s: needsCurrentProject = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
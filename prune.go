@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"text/tabwriter"
+)
+
+// mergedBranch is a topic branch whose work is already in the
+// integration branch.
+type mergedBranch struct {
+	Agent  string
+	Branch string
+	// How says how we know: "merged", "squash-merged" or "PR merged".
+	How string
+	// Remote is set when origin has the branch too.
+	Remote bool
+	// CheckedOut is set when a worktree has the branch checked out, so
+	// it can't be deleted yet.
+	CheckedOut bool
+}
+
+// mergedHow reports whether branch's work is in mainRef and how we can
+// tell, or "" when it isn't. useGH also asks GitHub about the branch's
+// pull request, which only counts if it was merged at the branch's
+// current tip.
+func mergedHow(dir string, branch string, mainRef string, useGH bool) string {
+	if _, err := gitOutput(dir, "merge-base", "--is-ancestor", branch, mainRef); err == nil {
+		return "merged"
+	}
+
+	// A squash merge is one commit with the branch's whole diff. Squash
+	// the branch the same way and ask cherry whether mainRef has a commit
+	// with the same patch id.
+	if base, err := gitOutput(dir, "merge-base", mainRef, branch); err == nil {
+		squashed, err := gitOutput(dir, "commit-tree", branch+"^{tree}", "-p", base, "-m", "squash "+branch)
+		if err == nil {
+			if cherry, err := gitOutput(dir, "cherry", mainRef, squashed); err == nil && strings.HasPrefix(cherry, "-") {
+				return "squash-merged"
+			}
+		}
+	}
+
+	if useGH {
+		pr, err := ghOutput(dir, "pr", "view", branch, "--json", "state,headRefOid", "--jq", `.state + " " + .headRefOid`)
+		tip, tipErr := gitOutput(dir, "rev-parse", branch)
+		// Commits made after the merge aren't in main yet
+		if err == nil && tipErr == nil && pr == "MERGED "+tip {
+			return "PR merged"
+		}
+	}
+	return ""
+}
+
+// untouchedBranch reports whether branch still points where it was
+// created, so nothing was ever committed on it. Such a branch is an
+// ancestor of mainRef without having been merged.
+func untouchedBranch(dir string, branch string) bool {
+	tip, err := gitOutput(dir, "rev-parse", branch)
+	if err != nil {
+		return false
+	}
+	reflog, err := gitOutput(dir, "reflog", "show", "--format=%H", "refs/heads/"+branch, "--")
+	if err != nil || reflog == "" {
+		return false
+	}
+	entries := strings.Split(reflog, "\n")
+	return entries[len(entries)-1] == tip
+}
+
+// findMergedBranches checks every agent's topic branches against mainRef,
+// skipping those with nothing committed yet or with stashed work.
+func findMergedBranches(cfg *ProjectConfig, mainRef string) ([]mergedBranch, error) {
+	worktrees, err := listWorktrees(cfg.Root)
+	if err != nil {
+		return nil, err
+	}
+	checkedOut := make(map[string]bool)
+	for _, wt := range worktrees {
+		checkedOut[wt.Branch] = true
+	}
+
+	_, ghErr := exec.LookPath("gh")
+	useGH := ghErr == nil
+	if !useGH {
+		LogDebug("gh not found, not checking pull requests")
+	}
+
+	var merged []mergedBranch
+	for i := range cfg.Agents {
+		agent := &cfg.Agents[i]
+		for _, branch := range topicBranches(cfg, agent) {
+			if untouchedBranch(cfg.Root, branch) {
+				LogDebug("Keeping %s: nothing committed on it", branch)
+				continue
+			}
+			if ref := findStash(cfg.Root, branch); ref != "" {
+				LogDebug("Keeping %s: it has stashed work in %s", branch, ref)
+				continue
+			}
+			how := mergedHow(cfg.Root, branch, mainRef, useGH)
+			if how == "" {
+				continue
+			}
+			merged = append(merged, mergedBranch{
+				Agent:      agent.Name,
+				Branch:     branch,
+				How:        how,
+				Remote:     refExists(cfg.Root, "refs/remotes/origin/"+branch),
+				CheckedOut: checkedOut[branch],
+			})
+		}
+	}
+	return merged, nil
+}
+
+// runWorktreePruneImpl deletes every agent's topic branches that have
+// been merged into the integration branch, locally and on origin. It
+// asks first unless yes is set, and only lists them with dryRun.
+func runWorktreePruneImpl(dryRun bool, yes bool) error {
+	cfg := CurrentConfig()
+	if cfg.Root == "" {
+		return fmt.Errorf("not in a git repository")
+	}
+	if _, err := gitOutput(cfg.Root, "remote", "get-url", "origin"); err == nil {
+		if _, err := gitOutput(cfg.Root, "fetch", "--prune", "origin"); err != nil {
+			PrintWarning("Could not fetch from origin, using what we have: %v", err)
+		}
+	}
+	mainRef := integrationRef(cfg.Root, integrationBranch(cfg, cfg.Root))
+	if !refExists(cfg.Root, mainRef) {
+		return fmt.Errorf("integration branch %s not found; set main_branch in %s", mainRef, ProjectConfigFile)
+	}
+
+	merged, err := findMergedBranches(cfg, mainRef)
+	if err != nil {
+		return err
+	}
+	if len(merged) == 0 {
+		PrintSuccess("No merged topic branches")
+		return nil
+	}
+
+	PrintHeader(fmt.Sprintf("Topic branches merged into %s", mainRef))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "AGENT\tBRANCH\tMERGED\tDELETE")
+	var deletable []mergedBranch
+	for _, m := range merged {
+		where := "local"
+		if m.Remote {
+			where = "local, origin"
+		}
+		if m.CheckedOut {
+			where = "no, checked out ('agenter worktree next' first)"
+		} else {
+			deletable = append(deletable, m)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", m.Agent, m.Branch, m.How, where)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Println()
+
+	if dryRun || len(deletable) == 0 {
+		return nil
+	}
+	if !yes && !Confirm(fmt.Sprintf("Delete %d branches?", len(deletable))) {
+		PrintInfo("Nothing deleted")
+		return nil
+	}
+
+	failed := 0
+	for _, m := range deletable {
+		// -D since a squash-merged branch isn't merged as far as git knows
+		if _, err := gitOutput(cfg.Root, "branch", "-D", m.Branch); err != nil {
+			PrintError("Could not delete %s: %v", m.Branch, err)
+			failed++
+			continue
+		}
+		if m.Remote {
+			if _, err := gitOutput(cfg.Root, "push", "--quiet", "origin", "--delete", m.Branch); err != nil {
+				PrintError("Could not delete %s on origin: %v", m.Branch, err)
+				failed++
+				continue
+			}
+		}
+		PrintSuccess("Deleted %s", m.Branch)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d branches could not be deleted", failed)
+	}
+	return nil
+}
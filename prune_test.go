@@ -0,0 +1,143 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// installFakeGH puts a gh first on PATH that runs script, with its
+// arguments logged to $HOME/gh-args.
+func installFakeGH(t *testing.T, script string) {
+	t.Helper()
+	binDir := t.TempDir()
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	script = "#!/bin/sh\necho \"$*\" >> \"$HOME/gh-args\"\n" + script
+	if err := os.WriteFile(filepath.Join(binDir, "gh"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// commitTopic commits file on a new topic branch in dir and returns to
+// the branch it started on.
+func commitTopic(t *testing.T, dir string, branch string, file string) {
+	t.Helper()
+	base := strings.TrimSpace(runTestGit(t, dir, "rev-parse", "--abbrev-ref", "HEAD"))
+	runTestGit(t, dir, "checkout", "-q", "-b", branch)
+	os.WriteFile(filepath.Join(dir, file), []byte(branch+"\n"), 0644)
+	runTestGit(t, dir, "add", file)
+	runTestGit(t, dir, "commit", "-q", "-m", "add "+file)
+	os.WriteFile(filepath.Join(dir, file), []byte(branch+" again\n"), 0644)
+	runTestGit(t, dir, "commit", "-q", "-am", "update "+file)
+	runTestGit(t, dir, "checkout", "-q", base)
+}
+
+func TestWorktreePruneFindsMergedBranches(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	runTestGit(t, repo, "init", "-q", "--bare", origin)
+	runTestGit(t, repo, "remote", "add", "origin", origin)
+	runTestGit(t, repo, "push", "-q", "-u", "origin", "main")
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	cfg := CurrentConfig()
+	forge, axiom := cfg.WorktreePath("forge"), cfg.WorktreePath("axiom")
+	// forge-worktree-pr was merged as it is; forge-worktree-moved got
+	// another commit after its PR was merged
+	installFakeGH(t, `case "$3" in
+forge-worktree-pr) echo "MERGED $(git rev-parse "$3")" ;;
+forge-worktree-moved) echo "MERGED $(cat "$HOME/moved-sha")" ;;
+*) exit 1 ;;
+esac
+`)
+
+	commitTopic(t, forge, "forge-worktree-merged", "merged.txt")
+	commitTopic(t, forge, "forge-worktree-squashed", "squashed.txt")
+	commitTopic(t, forge, "forge-worktree-open", "open.txt")
+	commitTopic(t, forge, "forge-worktree-pr", "pr.txt")
+	commitTopic(t, forge, "forge-worktree-moved", "moved.txt")
+	commitTopic(t, forge, "forge-worktree-stashed", "stashed.txt")
+	commitTopic(t, axiom, "axiom-worktree-current", "current.txt")
+	os.WriteFile(filepath.Join(os.Getenv("HOME"), "moved-sha"), []byte(runTestGit(t, forge, "rev-parse", "forge-worktree-moved")), 0644)
+	runTestGit(t, forge, "checkout", "-q", "forge-worktree-moved")
+	runTestGit(t, forge, "commit", "-q", "--allow-empty", "-m", "after the merge")
+	runTestGit(t, forge, "checkout", "-q", "forge-worktree")
+	// Just made and never committed on, so an ancestor of main
+	runTestGit(t, forge, "branch", "forge-worktree-empty")
+	runTestGit(t, forge, "push", "-q", "origin", "forge-worktree-merged", "forge-worktree-squashed")
+
+	runTestGit(t, repo, "merge", "-q", "--no-ff", "-m", "merge", "forge-worktree-merged")
+	runTestGit(t, repo, "merge", "-q", "--squash", "forge-worktree-squashed")
+	runTestGit(t, repo, "commit", "-q", "-m", "squashed")
+	runTestGit(t, repo, "merge", "-q", "axiom-worktree-current")
+	runTestGit(t, repo, "merge", "-q", "--no-ff", "-m", "merge", "forge-worktree-stashed")
+	// forge-worktree-stashed is merged but has work stashed for later
+	runTestGit(t, forge, "checkout", "-q", "forge-worktree-stashed")
+	os.WriteFile(filepath.Join(forge, "stashed.txt"), []byte("more\n"), 0644)
+	runTestGit(t, forge, "stash", "push", "-q", "-m", stashMessage("forge-worktree-stashed"))
+	runTestGit(t, forge, "checkout", "-q", "forge-worktree")
+	runTestGit(t, repo, "push", "-q", "origin", "main")
+	runTestGit(t, axiom, "checkout", "-q", "axiom-worktree-current")
+
+	merged, err := findMergedBranches(cfg, "origin/main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range merged {
+		got = append(got, m.Branch+":"+m.How)
+	}
+	want := "forge-worktree-merged:merged forge-worktree-pr:PR merged forge-worktree-squashed:squash-merged axiom-worktree-current:merged"
+	if strings.Join(got, " ") != want {
+		t.Errorf("merged branches:\n%s\nwant:\n%s", strings.Join(got, " "), want)
+	}
+
+	os.Chdir(repo)
+
+	// A dry run deletes nothing
+	if err := runWorktreePruneImpl(true, false); err != nil {
+		t.Fatal(err)
+	}
+	if !refExists(repo, "refs/heads/forge-worktree-merged") {
+		t.Fatal("dry run deleted a branch")
+	}
+
+	// Declining the prompt deletes nothing either
+	answer, _ := os.CreateTemp(t.TempDir(), "answer")
+	answer.WriteString("n\n")
+	answer.Seek(0, 0)
+	stdin := os.Stdin
+	os.Stdin = answer
+	err = runWorktreePruneImpl(false, false)
+	os.Stdin = stdin
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !refExists(repo, "refs/heads/forge-worktree-merged") {
+		t.Fatal("branch deleted without confirmation")
+	}
+
+	if err := runWorktreePruneImpl(false, true); err != nil {
+		t.Fatal(err)
+	}
+	for _, branch := range []string{"forge-worktree-merged", "forge-worktree-squashed", "forge-worktree-pr"} {
+		if refExists(repo, "refs/heads/"+branch) {
+			t.Errorf("%s not deleted", branch)
+		}
+		if refExists(origin, "refs/heads/"+branch) {
+			t.Errorf("%s not deleted on origin", branch)
+		}
+	}
+	// Unmerged, checked out, empty and stashed branches stay
+	for _, branch := range []string{"forge-worktree-open", "axiom-worktree-current", "forge-worktree-moved",
+		"forge-worktree-empty", "forge-worktree-stashed"} {
+		if !refExists(repo, "refs/heads/"+branch) {
+			t.Errorf("%s deleted", branch)
+		}
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"text/template"
+)
+
+// PullRequestConfig shapes the pull requests 'worktree push --pr' opens.
+// Title, Body and Labels are Go templates over pullRequestData.
+type PullRequestConfig struct {
+	Title     string   `yaml:"title,omitempty"`
+	Body      string   `yaml:"body,omitempty"`
+	Labels    []string `yaml:"labels,omitempty"`
+	Reviewers []string `yaml:"reviewers,omitempty"`
+	Draft     bool     `yaml:"draft,omitempty"`
+}
+
+const defaultPullRequestTitle = `{{if eq (len .Commits) 1}}{{index .Commits 0}}{{else}}{{.Summary}}{{end}}`
+
+const defaultPullRequestBody = `{{range .Commits}}- {{.}}
+{{end}}
+Opened by {{.Agent}} from {{.Branch}}.
+`
+
+// pullRequestData is what pull request templates can refer to.
+type pullRequestData struct {
+	Agent  string
+	Topic  string
+	Branch string
+	Base   string
+	// Summary is the topic as a sentence: "fix-flaky-tests" becomes
+	// "Fix flaky tests".
+	Summary string
+	// Commits are the subjects of the branch's commits, oldest first.
+	Commits []string
+}
+
+// pullRequestSpec is a pull request to open or update.
+type pullRequestSpec struct {
+	Branch    string
+	Base      string
+	Title     string
+	Body      string
+	Labels    []string
+	Reviewers []string
+	Draft     bool
+}
+
+// pushOptions are the flags of 'worktree push'.
+type pushOptions struct {
+	// PR opens (or updates) a pull request after pushing.
+	PR        bool
+	Draft     bool
+	Labels    []string
+	Reviewers []string
+}
+
+// pullRequestConfigKey is the git config key holding the URL of the
+// pull request opened for branch.
+func pullRequestConfigKey(branch string) string {
+	return "branch." + branch + ".agenter-pr"
+}
+
+// validate checks that the templates parse.
+func (c PullRequestConfig) validate() error {
+	for _, text := range append([]string{c.Title, c.Body}, c.Labels...) {
+		if _, err := template.New("pull_request").Option("missingkey=error").Parse(text); err != nil {
+			return fmt.Errorf("bad pull_request template: %v", err)
+		}
+	}
+	return nil
+}
+
+// renderPullRequestTemplate fills in one pull request template.
+func renderPullRequestTemplate(text string, data pullRequestData) (string, error) {
+	tmpl, err := template.New("pull_request").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("bad pull_request template: %v", err)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("bad pull_request template: %v", err)
+	}
+	return strings.TrimSpace(out.String()), nil
+}
+
+// topicSummary turns a topic into a title: "fix-flaky-tests" becomes
+// "Fix flaky tests".
+func topicSummary(topic string) string {
+	summary := strings.Join(strings.FieldsFunc(topic, func(r rune) bool {
+		return r == '-' || r == '_'
+	}), " ")
+	if summary == "" {
+		return topic
+	}
+	return strings.ToUpper(summary[:1]) + summary[1:]
+}
+
+// pullRequestSpecFor builds the pull request for agent's branch in dir
+// from the project's templates and opts.
+func pullRequestSpecFor(cfg *ProjectConfig, agent *AgentConfig, dir string, branch string, base string, opts pushOptions) (pullRequestSpec, error) {
+	data := pullRequestData{Agent: agent.Name, Branch: branch, Base: base, Topic: branch}
+	if topic, ok := cfg.TopicOf(agent, branch); ok {
+		data.Topic = topic
+	}
+	data.Summary = topicSummary(data.Topic)
+	if ref := integrationRef(dir, base); refExists(dir, ref) {
+		if log, err := gitOutput(dir, "log", "--reverse", "--format=%s", ref+".."+branch); err == nil && log != "" {
+			data.Commits = strings.Split(log, "\n")
+		}
+	}
+
+	pr := cfg.PullRequest
+	spec := pullRequestSpec{
+		Branch:    branch,
+		Base:      base,
+		Reviewers: append(append([]string{}, pr.Reviewers...), opts.Reviewers...),
+		Draft:     pr.Draft || opts.Draft,
+	}
+	title, body := pr.Title, pr.Body
+	if title == "" {
+		title = defaultPullRequestTitle
+	}
+	if body == "" {
+		body = defaultPullRequestBody
+	}
+	var err error
+	if spec.Title, err = renderPullRequestTemplate(title, data); err != nil {
+		return spec, err
+	}
+	if spec.Body, err = renderPullRequestTemplate(body, data); err != nil {
+		return spec, err
+	}
+	for _, label := range pr.Labels {
+		label, err := renderPullRequestTemplate(label, data)
+		if err != nil {
+			return spec, err
+		}
+		spec.Labels = append(spec.Labels, label)
+	}
+	spec.Labels = append(spec.Labels, opts.Labels...)
+	return spec, nil
+}
+
+// openGitHubPullRequest creates spec's pull request with gh, or updates
+// the open one at existing (a URL, or "" to look one up by branch). It
+// returns the pull request's URL and whether it was created.
+func openGitHubPullRequest(dir string, spec pullRequestSpec, existing string) (string, bool, error) {
+	if _, err := exec.LookPath("gh"); err != nil {
+		return "", false, fmt.Errorf("opening GitHub pull requests needs the GitHub CLI (gh)")
+	}
+
+	target := existing
+	if target == "" {
+		target = spec.Branch
+	}
+	if found, err := ghOutput(dir, "pr", "view", target, "--json", "state,url", "--jq", `.state + " " + .url`); err == nil {
+		if state, url, _ := strings.Cut(found, " "); state == "OPEN" {
+			args := []string{"pr", "edit", url, "--title", spec.Title, "--body", spec.Body}
+			for _, label := range spec.Labels {
+				args = append(args, "--add-label", label)
+			}
+			for _, reviewer := range spec.Reviewers {
+				args = append(args, "--add-reviewer", reviewer)
+			}
+			if _, err := ghOutput(dir, args...); err != nil {
+				return "", false, fmt.Errorf("could not update %s: %v", url, err)
+			}
+			return url, false, nil
+		}
+	}
+
+	args := []string{"pr", "create", "--head", spec.Branch, "--title", spec.Title, "--body", spec.Body}
+	if spec.Base != "" {
+		args = append(args, "--base", spec.Base)
+	}
+	if spec.Draft {
+		args = append(args, "--draft")
+	}
+	for _, label := range spec.Labels {
+		args = append(args, "--label", label)
+	}
+	for _, reviewer := range spec.Reviewers {
+		args = append(args, "--reviewer", reviewer)
+	}
+	output, err := ghOutput(dir, args...)
+	if err != nil {
+		return "", false, fmt.Errorf("could not create pull request: %v", err)
+	}
+	// gh prints progress before the URL
+	lines := strings.Split(output, "\n")
+	return strings.TrimSpace(lines[len(lines)-1]), true, nil
+}
+
+// openPullRequest opens or updates the pull request for agent's branch
+// in dir and remembers its URL against the branch.
+func openPullRequest(cfg *ProjectConfig, agent *AgentConfig, dir string, branch string, opts pushOptions) (string, error) {
+	remote, err := gitOutput(dir, "remote", "get-url", "origin")
+	if err != nil {
+		return "", fmt.Errorf("no origin remote to open a pull request on")
+	}
+	repo, err := parseRemoteURL(remote)
+	if err != nil {
+		return "", err
+	}
+	p, ok := cfg.providerFor(repo.Host)
+	if !ok {
+		return "", fmt.Errorf("unknown git host %s; add it under providers in %s", repo.Host, ProjectConfigFile)
+	}
+	base := integrationBranch(cfg, dir)
+	if p.OpenPullRequest == nil {
+		return "", fmt.Errorf("can't open %s pull requests yet; open one at %s", p.Name, p.NewPullRequestURL(repo, branch, base))
+	}
+
+	spec, err := pullRequestSpecFor(cfg, agent, dir, branch, base, opts)
+	if err != nil {
+		return "", err
+	}
+	existing, _ := gitOutput(dir, "config", "--get", pullRequestConfigKey(branch))
+	url, created, err := p.OpenPullRequest(dir, spec, existing)
+	if err != nil {
+		return "", err
+	}
+	if _, err := gitOutput(dir, "config", pullRequestConfigKey(branch), url); err != nil {
+		PrintWarning("Could not remember the pull request: %v", err)
+	}
+	if created {
+		PrintSuccess("Opened pull request: %s", spec.Title)
+	} else {
+		PrintSuccess("Updated pull request: %s", spec.Title)
+	}
+	return url, nil
+}
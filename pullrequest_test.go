@@ -0,0 +1,144 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTopicSummary(t *testing.T) {
+	tests := []struct {
+		topic string
+		want  string
+	}{
+		{"fix-flaky-tests", "Fix flaky tests"},
+		{"oauth_google", "Oauth google"},
+		{"v1.2", "V1.2"},
+		{"--", "--"},
+	}
+
+	for _, tt := range tests {
+		if got := topicSummary(tt.topic); got != tt.want {
+			t.Errorf("topicSummary(%q) = %q, want %q", tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestWorktreePushOpensPullRequest(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	config := `agents:
+  - name: forge
+pull_request:
+  body: "{{range .Commits}}* {{.}}\n{{end}}"
+  labels: ["agent:{{.Agent}}"]
+  reviewers: [alice]
+`
+	os.WriteFile(filepath.Join(repo, ProjectConfigFile), []byte(config), 0644)
+	runTestGit(t, repo, "add", ProjectConfigFile)
+	runTestGit(t, repo, "commit", "-q", "-m", "config")
+
+	// Pushes go to a local bare repository while origin looks like GitHub
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	runTestGit(t, repo, "init", "-q", "--bare", origin)
+	runTestGit(t, repo, "remote", "add", "origin", origin)
+	runTestGit(t, repo, "push", "-q", "-u", "origin", "main")
+	runTestGit(t, repo, "remote", "set-url", "origin", "git@github.com:owner/repo.git")
+	runTestGit(t, repo, "remote", "set-url", "--push", "origin", origin)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	home := os.Getenv("HOME")
+	installFakeGH(t, `case "$1 $2" in
+"pr view") [ -f "$HOME/pr-open" ] && echo "OPEN https://github.com/owner/repo/pull/9" && exit 0; exit 1 ;;
+"pr create") touch "$HOME/pr-open"; echo "Creating pull request..."; echo "https://github.com/owner/repo/pull/9" ;;
+esac
+`)
+	ghArgs := func() string {
+		data, _ := os.ReadFile(filepath.Join(home, "gh-args"))
+		return string(data)
+	}
+
+	forge := CurrentConfig().WorktreePath("forge")
+	os.Chdir(forge)
+	if err := runWorktreeMakeImpl("fix-flaky-tests", ""); err != nil {
+		t.Fatal(err)
+	}
+	for _, file := range []string{"a.txt", "b.txt"} {
+		os.WriteFile(filepath.Join(forge, file), []byte(file), 0644)
+		runTestGit(t, forge, "add", file)
+		runTestGit(t, forge, "commit", "-q", "-m", "add "+file)
+	}
+
+	opts := pushOptions{PR: true, Draft: true, Labels: []string{"bug"}}
+	if err := runWorktreePushImpl(opts); err != nil {
+		t.Fatal(err)
+	}
+	if !refExists(origin, "refs/heads/forge-worktree-fix-flaky-tests") {
+		t.Error("branch not pushed")
+	}
+	args := ghArgs()
+	for _, want := range []string{
+		"pr create --head forge-worktree-fix-flaky-tests --title Fix flaky tests --body * add a.txt\n* add b.txt --base main --draft",
+		"--label agent:forge --label bug --reviewer alice",
+	} {
+		if !strings.Contains(args, want) {
+			t.Errorf("gh args missing %q:\n%s", want, args)
+		}
+	}
+	key := pullRequestConfigKey("forge-worktree-fix-flaky-tests")
+	if url := strings.TrimSpace(runTestGit(t, forge, "config", "--get", key)); url != "https://github.com/owner/repo/pull/9" {
+		t.Errorf("stored PR = %q", url)
+	}
+
+	// Pushing again updates the pull request
+	os.Remove(filepath.Join(home, "gh-args"))
+	if err := runWorktreePushImpl(pushOptions{PR: true}); err != nil {
+		t.Fatal(err)
+	}
+	args = ghArgs()
+	if !strings.Contains(args, "pr view https://github.com/owner/repo/pull/9") ||
+		!strings.Contains(args, "pr edit https://github.com/owner/repo/pull/9 --title Fix flaky tests") ||
+		strings.Contains(args, "pr create") {
+		t.Errorf("expected the pull request to be updated:\n%s", args)
+	}
+
+	// Other hosts only get a link
+	runTestGit(t, forge, "remote", "set-url", "origin", "git@gitlab.com:owner/repo.git")
+	runTestGit(t, forge, "remote", "set-url", "--push", "origin", origin)
+	err := runWorktreePushImpl(pushOptions{PR: true})
+	if err == nil || !strings.Contains(err.Error(), "can't open GitLab pull requests yet") {
+		t.Errorf("expected GitLab to be unsupported, got %v", err)
+	}
+}
+
+func TestWorktreePushFromMainCheckout(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	runTestGit(t, repo, "init", "-q", "--bare", origin)
+	runTestGit(t, repo, "remote", "add", "origin", origin)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	os.Chdir(repo)
+	runTestGit(t, repo, "checkout", "-q", "-b", "feature")
+
+	// Not an agent worktree: the branch is pushed without agent extras
+	if err := runWorktreePushImpl(pushOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if !refExists(origin, "refs/heads/feature") {
+		t.Error("feature not pushed")
+	}
+	err := runWorktreePushImpl(pushOptions{PR: true})
+	if err == nil || !strings.Contains(err.Error(), "--pr only works in an agent worktree") {
+		t.Errorf("expected --pr to need an agent worktree, got %v", err)
+	}
+}
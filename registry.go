@@ -0,0 +1,254 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// GlobalConfig is ~/.agenter/config.yaml. For now it only holds the
+// registry of projects that have been set up.
+type GlobalConfig struct {
+	Projects []ProjectEntry `yaml:"projects,omitempty"`
+}
+
+// ProjectEntry records one repository set up with agenter.
+type ProjectEntry struct {
+	Name         string            `yaml:"name" json:"name"`
+	Path         string            `yaml:"path" json:"path"`
+	Agents       []string          `yaml:"agents" json:"agents"`
+	Worktrees    map[string]string `yaml:"worktrees,omitempty" json:"worktrees,omitempty"`
+	Created      time.Time         `yaml:"created" json:"created"`
+	LastLaunched *time.Time        `yaml:"last_launched,omitempty" json:"last_launched,omitempty"`
+}
+
+// globalConfigPath returns the location of the global config file.
+func globalConfigPath() string {
+	return filepath.Join(agenterDir(), "config.yaml")
+}
+
+// LoadGlobalConfig reads the global config. A missing file is an empty config.
+func LoadGlobalConfig() (*GlobalConfig, error) {
+	g := &GlobalConfig{}
+	data, err := os.ReadFile(globalConfigPath())
+	if os.IsNotExist(err) {
+		return g, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, g); err != nil {
+		return nil, fmt.Errorf("%s: %v", FormatPath(globalConfigPath()), err)
+	}
+	return g, nil
+}
+
+// Save writes the global config, replacing the old file atomically so a
+// crash never leaves a half-written registry behind.
+func (g *GlobalConfig) Save() error {
+	data, err := yaml.Marshal(g)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(globalConfigPath(), data, 0644)
+}
+
+// writeFileAtomic writes data to a temp file next to path and renames it
+// into place, creating the parent directory when needed.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Project returns the entry for the main repository at path, or nil.
+func (g *GlobalConfig) Project(path string) *ProjectEntry {
+	for i := range g.Projects {
+		if g.Projects[i].Path == path {
+			return &g.Projects[i]
+		}
+	}
+	return nil
+}
+
+// ProjectsNamed returns every entry called name. Names aren't unique,
+// since two checkouts of the same repository share one.
+func (g *GlobalConfig) ProjectsNamed(name string) []ProjectEntry {
+	var matches []ProjectEntry
+	for _, entry := range g.Projects {
+		if entry.Name == name {
+			matches = append(matches, entry)
+		}
+	}
+	return matches
+}
+
+// Upsert adds entry or replaces the one with the same path. The original
+// creation time is kept so re-running setup doesn't reset it.
+func (g *GlobalConfig) Upsert(entry ProjectEntry) {
+	if existing := g.Project(entry.Path); existing != nil {
+		entry.Created = existing.Created
+		if entry.LastLaunched == nil {
+			entry.LastLaunched = existing.LastLaunched
+		}
+		*existing = entry
+		return
+	}
+	g.Projects = append(g.Projects, entry)
+}
+
+// Remove drops the entry for path. It reports whether one was found.
+func (g *GlobalConfig) Remove(path string) bool {
+	for i := range g.Projects {
+		if g.Projects[i].Path == path {
+			g.Projects = append(g.Projects[:i], g.Projects[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Prune drops projects whose repository no longer exists and forgets
+// worktrees that have been deleted. It returns the dropped projects.
+func (g *GlobalConfig) Prune() []ProjectEntry {
+	var kept, dropped []ProjectEntry
+	for _, entry := range g.Projects {
+		if _, err := os.Stat(entry.Path); err != nil {
+			dropped = append(dropped, entry)
+			continue
+		}
+		for agent, path := range entry.Worktrees {
+			if _, err := os.Stat(path); err != nil {
+				delete(entry.Worktrees, agent)
+			}
+		}
+		kept = append(kept, entry)
+	}
+	g.Projects = kept
+	return dropped
+}
+
+// RegisterProject records cfg's repository and its existing worktrees.
+func RegisterProject(cfg *ProjectConfig) error {
+	g, err := LoadGlobalConfig()
+	if err != nil {
+		return err
+	}
+
+	entry := ProjectEntry{
+		Name:      cfg.Name,
+		Path:      cfg.Root,
+		Agents:    cfg.AgentNames(),
+		Worktrees: make(map[string]string),
+		Created:   time.Now().UTC().Truncate(time.Second),
+	}
+	for _, agent := range cfg.Agents {
+		path := cfg.WorktreePath(agent.Name)
+		if _, err := os.Stat(path); err == nil {
+			entry.Worktrees[agent.Name] = path
+		}
+	}
+
+	g.Upsert(entry)
+	return g.Save()
+}
+
+// TouchProject updates the last-launched time for the repository at root.
+// Unregistered projects are left alone.
+func TouchProject(root string) error {
+	g, err := LoadGlobalConfig()
+	if err != nil {
+		return err
+	}
+	entry := g.Project(root)
+	if entry == nil {
+		return nil
+	}
+	now := time.Now().UTC().Truncate(time.Second)
+	entry.LastLaunched = &now
+	return g.Save()
+}
+
+// runListImpl implements the list command
+func runListImpl(asJSON bool, prune bool) error {
+	g, err := LoadGlobalConfig()
+	if err != nil {
+		return err
+	}
+
+	if prune {
+		dropped := g.Prune()
+		if err := g.Save(); err != nil {
+			return fmt.Errorf("could not save %s: %v", FormatPath(globalConfigPath()), err)
+		}
+		if !asJSON {
+			for _, entry := range dropped {
+				PrintInfo("Removed %s (%s no longer exists)", entry.Name, FormatPath(entry.Path))
+			}
+		}
+	}
+
+	projects := g.Projects
+	sort.Slice(projects, func(i, j int) bool {
+		return projects[i].Name < projects[j].Name
+	})
+
+	if asJSON {
+		if projects == nil {
+			projects = []ProjectEntry{}
+		}
+		data, err := json.MarshalIndent(projects, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	PrintHeader("Projects")
+	if len(projects) == 0 {
+		PrintInfo("No projects configured")
+		PrintInfo("Run 'agenter setup <repository>' to add one")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tPATH\tAGENTS\tCREATED\tLAST LAUNCHED")
+	for _, entry := range projects {
+		lastLaunched := "never"
+		if entry.LastLaunched != nil {
+			lastLaunched = FormatAge(*entry.LastLaunched)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n",
+			entry.Name,
+			FormatPath(entry.Path),
+			strings.Join(entry.Agents, ","),
+			FormatAge(entry.Created),
+			lastLaunched)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGlobalConfigUpsertKeepsCreatedTime(t *testing.T) {
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	g := &GlobalConfig{}
+	g.Upsert(ProjectEntry{Name: "app", Path: "/src/app", Created: created})
+	g.Upsert(ProjectEntry{Name: "app", Path: "/src/app", Agents: []string{"forge"}, Created: time.Now()})
+
+	if len(g.Projects) != 1 {
+		t.Fatalf("expected one project, got %d", len(g.Projects))
+	}
+	entry := g.Project("/src/app")
+	if !entry.Created.Equal(created) {
+		t.Errorf("Created = %v, want %v", entry.Created, created)
+	}
+	if len(entry.Agents) != 1 {
+		t.Errorf("expected agents to be replaced, got %v", entry.Agents)
+	}
+}
+
+func TestGlobalConfigPruneDropsMissingPaths(t *testing.T) {
+	dir := t.TempDir()
+	kept := filepath.Join(dir, "kept")
+	os.Mkdir(kept, 0755)
+
+	g := &GlobalConfig{Projects: []ProjectEntry{
+		{Name: "kept", Path: kept, Worktrees: map[string]string{
+			"forge": kept,
+			"axiom": filepath.Join(dir, "gone-axiom"),
+		}},
+		{Name: "gone", Path: filepath.Join(dir, "gone")},
+	}}
+
+	dropped := g.Prune()
+	if len(dropped) != 1 || dropped[0].Name != "gone" {
+		t.Errorf("dropped = %v, want [gone]", dropped)
+	}
+	if len(g.Projects) != 1 || g.Projects[0].Name != "kept" {
+		t.Fatalf("projects = %v, want [kept]", g.Projects)
+	}
+	if _, ok := g.Projects[0].Worktrees["axiom"]; ok {
+		t.Error("expected missing axiom worktree to be forgotten")
+	}
+}
+
+func TestSetupRegistersProject(t *testing.T) {
+	repo := newTestRepo(t)
+
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatalf("setup failed: %v", err)
+	}
+	if err := TouchProject(repo); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := LoadGlobalConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := g.Project(repo)
+	if entry == nil {
+		t.Fatalf("project %s not registered: %+v", repo, g.Projects)
+	}
+	if entry.Name != "project" || len(entry.Agents) != 3 {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if got := entry.Worktrees["forge"]; got != filepath.Join(filepath.Dir(repo), "project-forge") {
+		t.Errorf("forge worktree = %q", got)
+	}
+	if entry.LastLaunched == nil {
+		t.Error("expected last launch time to be set")
+	}
+}
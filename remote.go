@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// remoteRepo is a repository on a git host, parsed from a remote URL.
+type remoteRepo struct {
+	// Scheme is how to reach the host's web pages: https, or http for
+	// remotes cloned over plain http.
+	Scheme string
+	// Host includes the port for http(s) remotes. SSH ports are dropped
+	// since they aren't the web server's.
+	Host string
+	// Path is the repository path without .git, e.g. "owner/repo" or
+	// "group/subgroup/repo".
+	Path string
+}
+
+// WebURL returns the repository's home page.
+func (r remoteRepo) WebURL() string {
+	return fmt.Sprintf("%s://%s/%s", r.Scheme, r.Host, r.Path)
+}
+
+// parseRemoteURL understands scp-style (git@host:owner/repo.git),
+// ssh://, git:// and http(s) remotes, with or without credentials.
+func parseRemoteURL(raw string) (remoteRepo, error) {
+	raw = strings.TrimSpace(raw)
+	repo := remoteRepo{Scheme: "https"}
+
+	if !strings.Contains(raw, "://") {
+		// scp-style: [user@]host:path. A slash before the colon makes it a
+		// local path instead.
+		hostPart, path, ok := strings.Cut(raw, ":")
+		if !ok || hostPart == "" || strings.Contains(hostPart, "/") {
+			return remoteRepo{}, fmt.Errorf("%q is not a remote URL", raw)
+		}
+		if at := strings.LastIndex(hostPart, "@"); at >= 0 {
+			hostPart = hostPart[at+1:]
+		}
+		repo.Host = hostPart
+		repo.Path = path
+	} else {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return remoteRepo{}, fmt.Errorf("could not parse remote URL: %v", err)
+		}
+		switch u.Scheme {
+		case "https", "http":
+			repo.Scheme = u.Scheme
+			repo.Host = u.Host
+		case "ssh", "git", "git+ssh", "ssh+git":
+			repo.Host = u.Hostname()
+		default:
+			return remoteRepo{}, fmt.Errorf("unsupported remote URL scheme %q", u.Scheme)
+		}
+		repo.Path = u.Path
+	}
+
+	repo.Host = strings.ToLower(repo.Host)
+	repo.Path = strings.TrimSuffix(strings.Trim(repo.Path, "/"), ".git")
+	// ssh://host/~user/repo is relative to a home directory, not a web path
+	if repo.Host == "" || repo.Path == "" || strings.HasPrefix(repo.Path, "~") {
+		return remoteRepo{}, fmt.Errorf("%q has no host and repository path", raw)
+	}
+	return repo, nil
+}
+
+// provider is a kind of git host that we can link to.
+type provider struct {
+	Name string
+	// NewPullRequestURL is where to open a pull (or merge) request of
+	// branch into base.
+	NewPullRequestURL func(repo remoteRepo, branch string, base string) string
+	// OpenPullRequest creates or updates a pull request, if we can do
+	// that for this provider.
+	OpenPullRequest func(dir string, spec pullRequestSpec, existing string) (string, bool, error)
+}
+
+// providers are the supported kinds of git host by name.
+var providers = map[string]provider{
+	"github": {
+		Name: "GitHub",
+		NewPullRequestURL: func(repo remoteRepo, branch string, base string) string {
+			return fmt.Sprintf("%s/pull/new/%s", repo.WebURL(), branch)
+		},
+		OpenPullRequest: openGitHubPullRequest,
+	},
+	"gitlab": {
+		Name: "GitLab",
+		NewPullRequestURL: func(repo remoteRepo, branch string, base string) string {
+			query := url.Values{"merge_request[source_branch]": {branch}}
+			if base != "" {
+				query.Set("merge_request[target_branch]", base)
+			}
+			return fmt.Sprintf("%s/-/merge_requests/new?%s", repo.WebURL(), query.Encode())
+		},
+	},
+	"bitbucket": {
+		Name: "Bitbucket",
+		NewPullRequestURL: func(repo remoteRepo, branch string, base string) string {
+			query := url.Values{"source": {branch}}
+			if base != "" {
+				query.Set("dest", base)
+			}
+			return fmt.Sprintf("%s/pull-requests/new?%s", repo.WebURL(), query.Encode())
+		},
+	},
+	"gitea": {
+		Name: "Gitea",
+		NewPullRequestURL: func(repo remoteRepo, branch string, base string) string {
+			// Gitea's compare page needs the base; main is its default
+			if base == "" {
+				base = "main"
+			}
+			return fmt.Sprintf("%s/compare/%s...%s", repo.WebURL(), base, branch)
+		},
+	},
+}
+
+// knownHosts are public hosts whose provider we know without config.
+var knownHosts = map[string]string{
+	"github.com":    "github",
+	"gitlab.com":    "gitlab",
+	"bitbucket.org": "bitbucket",
+	"gitea.com":     "gitea",
+	"codeberg.org":  "gitea",
+}
+
+// validateProviders checks that config maps hosts to known providers.
+func validateProviders(hosts map[string]string) error {
+	for host, name := range hosts {
+		if _, ok := providers[name]; !ok {
+			var names []string
+			for name := range providers {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return fmt.Errorf("host %s has unknown provider %q (use %s)", host, name, describeAgentNames(names))
+		}
+	}
+	return nil
+}
+
+// providerFor returns the provider for host: from the project's
+// providers config, a known public host, or a guess from the host name.
+func (c *ProjectConfig) providerFor(host string) (provider, bool) {
+	name, ok := c.Providers[host]
+	if !ok {
+		// The config may name the host without the port
+		if h, _, found := strings.Cut(host, ":"); found {
+			name, ok = c.Providers[h]
+		}
+	}
+	if !ok {
+		name, ok = knownHosts[host]
+	}
+	if !ok {
+		// Self-hosted instances often say what they are, as in
+		// gitlab.example.com or github.example.com
+		for _, guess := range []string{"github", "gitlab", "gitea", "bitbucket"} {
+			if strings.Contains(host, guess) {
+				name, ok = guess, true
+				break
+			}
+		}
+	}
+	if !ok {
+		return provider{}, false
+	}
+	return providers[name], true
+}
+
+// PullRequestURL returns where to open a pull request for branch on the
+// remote at rawURL, or "" if we don't know the host.
+func (c *ProjectConfig) PullRequestURL(rawURL string, branch string, base string) string {
+	repo, err := parseRemoteURL(rawURL)
+	if err != nil {
+		LogDebug("No pull request link: %v", err)
+		return ""
+	}
+	p, ok := c.providerFor(repo.Host)
+	if !ok {
+		LogDebug("No pull request link: unknown host %s; add it under providers in %s", repo.Host, ProjectConfigFile)
+		return ""
+	}
+	return p.NewPullRequestURL(repo, branch, base)
+}
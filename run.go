@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// runOptions are the command-line choices for one headless run.
+type runOptions struct {
+	// Project names the project when running from outside it.
+	Project string
+	// Prompt is the task for the agent, or PromptFile names a file holding
+	// it ("-" for stdin).
+	Prompt     string
+	PromptFile string
+	// Topic creates a topic branch before the agent starts.
+	Topic string
+	// Issue fills in {issue} in the topic branch name.
+	Issue string
+	// Push pushes the topic branch once the agent succeeds.
+	Push bool
+	// Args go to the agent's tool after its configured args.
+	Args []string
+	// Sandbox runs the tool with the filesystem locked down.
+	Sandbox bool
+	// Timeout overrides the configured time box when set.
+	Timeout time.Duration
+}
+
+// readPrompt returns the prompt from opts, reading the prompt file if
+// one was given.
+func readPrompt(opts runOptions) (string, error) {
+	if opts.Prompt != "" && opts.PromptFile != "" {
+		return "", fmt.Errorf("give a prompt or --prompt-file, not both")
+	}
+
+	prompt := opts.Prompt
+	if opts.PromptFile != "" {
+		var data []byte
+		var err error
+		if opts.PromptFile == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(opts.PromptFile)
+		}
+		if err != nil {
+			return "", fmt.Errorf("could not read prompt: %v", err)
+		}
+		prompt = string(data)
+	}
+
+	if strings.TrimSpace(prompt) == "" {
+		return "", fmt.Errorf("no prompt given")
+	}
+	return prompt, nil
+}
+
+// runRunImpl runs agent's tool headless on one prompt. Output goes to
+// the terminal and a session log, and a failing tool is returned as its
+// *exec.ExitError so the caller can pass the exit code on.
+func runRunImpl(agent string, opts runOptions) error {
+	prompt, err := readPrompt(opts)
+	if err != nil {
+		return err
+	}
+	if opts.Push && opts.Topic == "" {
+		return fmt.Errorf("--push needs a --topic to push")
+	}
+
+	dir, err := prepareAgentDir(agent, opts.Project)
+	if err != nil {
+		return err
+	}
+
+	lock, err := acquireAgentLock(dir, agent)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	// The worktree commands work on the current directory
+	originalDir, err := os.Getwd()
+	if err != nil {
+		return fmt.Errorf("failed to get current directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		return err
+	}
+	defer os.Chdir(originalDir)
+
+	if opts.Topic != "" {
+		if err := runWorktreeMakeImpl(opts.Topic, opts.Issue); err != nil {
+			return err
+		}
+	}
+
+	if err := runHeadless(agent, dir, prompt, opts, false); err != nil {
+		return err
+	}
+	PrintSuccess("%s finished", PrintAgent(agent))
+
+	if opts.Push {
+		return runWorktreePushImpl(pushOptions{})
+	}
+	return nil
+}
+
+// runHeadless runs one headless session of agent's tool in dir, logged
+// to a session file. With ownGroup the tool gets its own process group,
+// so terminal signals reach it only through us.
+func runHeadless(agent string, dir string, prompt string, opts runOptions, ownGroup bool) error {
+	agentCfg := CurrentConfig().Agent(agent)
+	if err := runHooks("pre_launch", agentCfg, dir); err != nil {
+		return err
+	}
+
+	limits, err := agentLimits(agentCfg, opts.Timeout)
+	if err != nil {
+		return err
+	}
+	cmd, err := agentCommand(agent, dir, opts.Args, prompt, opts.Sandbox)
+	if err != nil {
+		return err
+	}
+	if err := applyLimits(cmd, limits); err != nil {
+		return err
+	}
+	if ownGroup {
+		cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	}
+
+	session, err := startSession(CurrentConfig(), agent, dir)
+	if err != nil {
+		return fmt.Errorf("could not start session log: %v", err)
+	}
+	logFile, err := os.OpenFile(session.Path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	defer logFile.Close()
+	cmd.Stdout = io.MultiWriter(os.Stdout, logFile)
+	cmd.Stderr = io.MultiWriter(os.Stderr, logFile)
+
+	PrintInfo("Running %s headless, logging to %s", PrintAgent(agent), FormatPath(session.Path))
+	err = runForeground(cmd, limits)
+	outcome := timeBoxOutcome(err, dir, agent)
+	runHooks("post_exit", agentCfg, dir, "AGENTER_EXIT_STATUS="+exitStatus(err))
+
+	if finishErr := finishSession(session, exitStatus(err)); finishErr != nil {
+		PrintWarning("Could not finish session log: %v", finishErr)
+	}
+	if outcome != "" {
+		appendSessionNote(session, outcome)
+	}
+	return err
+}
+
+// exitCode is the status agenter should exit with for err, passing a
+// child's own exit code through.
+func exitCode(err error) int {
+	// Same as timeout(1)
+	if _, ok := err.(*timeoutError); ok {
+		return 124
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		// Same as a shell for a child killed by a signal
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal())
+		}
+		if exitErr.ExitCode() > 0 {
+			return exitErr.ExitCode()
+		}
+	}
+	return 1
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// installFakeHeadlessClaude points AGENTER_CLAUDE at a script that saves
+// its prompt to $HOME/prompt, commits a file, and exits with $FAKE_EXIT.
+func installFakeHeadlessClaude(t *testing.T) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fake-claude")
+	script := "#!/bin/sh\n" +
+		"cat > \"$HOME/prompt\"\n" +
+		"echo \"$*\" > \"$HOME/launched-args\"\n" +
+		"echo \"working on it\"\n" +
+		"echo done > chore.txt && git add chore.txt && git commit -q -m chore\n" +
+		"exit ${FAKE_EXIT:-0}\n"
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AGENTER_CLAUDE", path)
+}
+
+func TestRunHeadlessOnTopicAndPush(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	runTestGit(t, repo, "init", "-q", "--bare", origin)
+	runTestGit(t, repo, "remote", "add", "origin", origin)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	installFakeHeadlessClaude(t)
+	home := os.Getenv("HOME")
+
+	promptFile := filepath.Join(t.TempDir(), "bump.md")
+	os.WriteFile(promptFile, []byte("Bump the dependencies\n"), 0644)
+
+	os.Chdir(repo)
+	opts := runOptions{PromptFile: promptFile, Topic: "deps", Push: true, Args: []string{"--model", "x"}}
+	if err := runRunImpl("forge", opts); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	if cwd, _ := os.Getwd(); cwd != repo {
+		t.Errorf("run left us in %s", cwd)
+	}
+	prompt, _ := os.ReadFile(filepath.Join(home, "prompt"))
+	if string(prompt) != "Bump the dependencies\n" {
+		t.Errorf("prompt = %q", prompt)
+	}
+	args, _ := os.ReadFile(filepath.Join(home, "launched-args"))
+	if got := strings.TrimSpace(string(args)); got != "-p --model x" {
+		t.Errorf("claude args = %q", got)
+	}
+
+	forge := CurrentConfig().WorktreePath("forge")
+	if branch, _ := gitOutput(forge, "rev-parse", "--abbrev-ref", "HEAD"); branch != "forge-worktree-deps" {
+		t.Errorf("worktree on %s, want the topic branch", branch)
+	}
+	if !refExists(origin, "refs/heads/forge-worktree-deps") {
+		t.Error("topic branch was not pushed")
+	}
+
+	sessions, _ := listSessions(CurrentConfig(), "forge")
+	if len(sessions) != 1 || sessions[0].Exit != "0" {
+		t.Fatalf("sessions = %+v", sessions)
+	}
+	log, _ := os.ReadFile(sessions[0].Path)
+	if !strings.Contains(string(log), "working on it") {
+		t.Errorf("output not logged:\n%s", log)
+	}
+}
+
+func TestRunPassesExitCodeAndSkipsPush(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	installFakeHeadlessClaude(t)
+	t.Setenv("FAKE_EXIT", "3")
+	os.Chdir(repo)
+
+	// Without an origin the push would fail, so an ExitError shows it was
+	// never attempted
+	err := runRunImpl("forge", runOptions{Prompt: "lint", Topic: "lint", Push: true})
+	if err == nil {
+		t.Fatal("expected failure")
+	}
+	if code := exitCode(err); code != 3 {
+		t.Errorf("exit code = %d (%v), want 3", code, err)
+	}
+	if sessions, _ := listSessions(CurrentConfig(), "forge"); len(sessions) != 1 || sessions[0].Exit != "3" {
+		t.Errorf("sessions = %+v", sessions)
+	}
+}
+
+func TestReadPrompt(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    runOptions
+		wantErr bool
+	}{
+		{"inline", runOptions{Prompt: "fix lint"}, false},
+		{"both", runOptions{Prompt: "a", PromptFile: "b"}, true},
+		{"neither", runOptions{}, true},
+		{"blank", runOptions{Prompt: "  \n"}, true},
+		{"missing file", runOptions{PromptFile: "/does/not/exist"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readPrompt(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("readPrompt() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+// sandboxPolicy is what a sandboxed agent may touch. Everything under
+// Parent is read-only except the agent's worktree, the shared git dir
+// and any extra Writable paths; Hide paths are masked entirely. The rest
+// of the filesystem is left alone so Claude keeps its own config.
+type sandboxPolicy struct {
+	Parent    string
+	Worktree  string
+	CommonDir string
+	Writable  []string
+	Hide      []string
+}
+
+// newSandboxPolicy builds the policy for an agent running in worktree.
+// All paths are resolved through symlinks, since that's what the kernel
+// sees.
+func newSandboxPolicy(cfg *ProjectConfig, worktree string) (*sandboxPolicy, error) {
+	root := cfg.Root
+	if root == "" {
+		var err error
+		if root, err = findMainRepo(worktree); err != nil {
+			return nil, err
+		}
+	}
+
+	commonDir, err := gitCommonDir(worktree)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &sandboxPolicy{}
+	for _, p := range []struct {
+		dst  *string
+		path string
+	}{
+		{&policy.Parent, filepath.Dir(root)},
+		{&policy.Worktree, worktree},
+		{&policy.CommonDir, commonDir},
+	} {
+		if *p.dst, err = filepath.EvalSymlinks(p.path); err != nil {
+			return nil, err
+		}
+	}
+
+	policy.Writable = sandboxPaths(root, cfg.Sandbox.Writable)
+	policy.Hide = sandboxPaths(root, cfg.Sandbox.Hide)
+	return policy, nil
+}
+
+// sandboxPaths resolves configured paths against root, dropping any that
+// don't exist since there's nothing to mount over.
+func sandboxPaths(root string, paths []string) []string {
+	var resolved []string
+	for _, path := range paths {
+		path = expandHome(path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		real, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			LogDebug("Sandbox: skipping %s: %v", path, err)
+			continue
+		}
+		resolved = append(resolved, real)
+	}
+	return resolved
+}
+
+// applySandbox wraps cmd so it runs under the agent's sandbox.
+func applySandbox(cmd *exec.Cmd, cfg *ProjectConfig) error {
+	policy, err := newSandboxPolicy(cfg, cmd.Dir)
+	if err != nil {
+		return fmt.Errorf("could not set up sandbox: %v", err)
+	}
+	return sandboxCommand(cmd, policy)
+}
+
+// isDir reports whether path is an existing directory.
+func isDir(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// sandboxPolicyEnv hands the Landlock rules to the __sandbox-exec helper.
+const sandboxPolicyEnv = "AGENTER_SANDBOX_POLICY"
+
+// sandboxCommand rewrites cmd to run under bubblewrap, or under Landlock
+// through our own __sandbox-exec helper when bwrap isn't installed.
+func sandboxCommand(cmd *exec.Cmd, policy *sandboxPolicy) error {
+	if bwrap, err := exec.LookPath("bwrap"); err == nil {
+		LogDebug("Sandboxing with %s", bwrap)
+		cmd.Path = bwrap
+		cmd.Args = append(bwrapArgs(policy), cmd.Args...)
+		return nil
+	}
+
+	if landlockABI() < 1 {
+		return fmt.Errorf("--sandbox needs bubblewrap (bwrap) or a kernel with Landlock enabled")
+	}
+	if len(policy.Hide) > 0 {
+		PrintWarning("Landlock can't hide paths, install bubblewrap to hide %s", strings.Join(policy.Hide, ", "))
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	rules, err := json.Marshal(landlockWritable(policy))
+	if err != nil {
+		return err
+	}
+	LogDebug("Sandboxing with Landlock ABI %d", landlockABI())
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, sandboxPolicyEnv+"="+string(rules))
+	cmd.Path = self
+	cmd.Args = append([]string{self, "__sandbox-exec"}, cmd.Args...)
+	return nil
+}
+
+// bwrapArgs mounts the parent dir read-only over an otherwise unchanged
+// root, then the writable paths back on top, then the hidden ones.
+func bwrapArgs(policy *sandboxPolicy) []string {
+	args := []string{"bwrap", "--die-with-parent", "--bind", "/", "/", "--ro-bind", policy.Parent, policy.Parent}
+	for _, path := range append([]string{policy.Worktree, policy.CommonDir}, policy.Writable...) {
+		args = append(args, "--bind", path, path)
+	}
+	for _, path := range policy.Hide {
+		if isDir(path) {
+			args = append(args, "--tmpfs", path)
+		} else {
+			args = append(args, "--ro-bind", "/dev/null", path)
+		}
+	}
+	return append(args, "--")
+}
+
+// landlockWritable lists the paths to grant write access to. Landlock
+// can only grant, not deny, so instead of making the parent dir
+// read-only we allow everything beside the path from / down to it.
+// Symlinks are skipped, since one could point back into the parent.
+func landlockWritable(policy *sandboxPolicy) []string {
+	paths := append([]string{policy.Worktree, policy.CommonDir}, policy.Writable...)
+	for dir := policy.Parent; dir != filepath.Dir(dir); dir = filepath.Dir(dir) {
+		up := filepath.Dir(dir)
+		entries, err := os.ReadDir(up)
+		if err != nil {
+			LogDebug("Sandbox: could not read %s: %v", up, err)
+			continue
+		}
+		for _, entry := range entries {
+			path := filepath.Join(up, entry.Name())
+			if path != dir && entry.Type()&os.ModeSymlink == 0 {
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// landlockABI returns the kernel's Landlock version, or 0 without it.
+func landlockABI() int {
+	abi, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, 0, 0, unix.LANDLOCK_CREATE_RULESET_VERSION)
+	if errno != 0 {
+		return 0
+	}
+	return int(abi)
+}
+
+// landlockWriteAccess is every write right the kernel's ABI knows about.
+// Reads and execution aren't handled, so they stay allowed everywhere.
+func landlockWriteAccess(abi int) uint64 {
+	access := uint64(unix.LANDLOCK_ACCESS_FS_WRITE_FILE |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_DIR |
+		unix.LANDLOCK_ACCESS_FS_REMOVE_FILE |
+		unix.LANDLOCK_ACCESS_FS_MAKE_CHAR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_DIR |
+		unix.LANDLOCK_ACCESS_FS_MAKE_REG |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_FIFO |
+		unix.LANDLOCK_ACCESS_FS_MAKE_BLOCK |
+		unix.LANDLOCK_ACCESS_FS_MAKE_SYM)
+	if abi >= 2 {
+		access |= unix.LANDLOCK_ACCESS_FS_REFER
+	}
+	if abi >= 3 {
+		access |= unix.LANDLOCK_ACCESS_FS_TRUNCATE
+	}
+	return access
+}
+
+// landlockRestrict limits writes by this thread, and whatever it execs,
+// to the given paths.
+func landlockRestrict(paths []string) error {
+	abi := landlockABI()
+	if abi < 1 {
+		return fmt.Errorf("Landlock is not available")
+	}
+	handled := landlockWriteAccess(abi)
+	fileAccess := handled & (unix.LANDLOCK_ACCESS_FS_WRITE_FILE | unix.LANDLOCK_ACCESS_FS_TRUNCATE)
+
+	attr := unix.LandlockRulesetAttr{Access_fs: handled}
+	fd, _, errno := unix.Syscall(unix.SYS_LANDLOCK_CREATE_RULESET, uintptr(unsafe.Pointer(&attr)), unsafe.Sizeof(attr), 0)
+	if errno != 0 {
+		return fmt.Errorf("could not create Landlock ruleset: %v", errno)
+	}
+	ruleset := int(fd)
+	defer unix.Close(ruleset)
+
+	for _, path := range paths {
+		f, err := unix.Open(path, unix.O_PATH|unix.O_CLOEXEC, 0)
+		if err != nil {
+			continue
+		}
+		rule := unix.LandlockPathBeneathAttr{Allowed_access: handled, Parent_fd: int32(f)}
+		if !isDir(path) {
+			rule.Allowed_access = fileAccess
+		}
+		_, _, errno := unix.Syscall6(unix.SYS_LANDLOCK_ADD_RULE, uintptr(ruleset), unix.LANDLOCK_RULE_PATH_BENEATH, uintptr(unsafe.Pointer(&rule)), 0, 0, 0)
+		unix.Close(f)
+		if errno != 0 {
+			return fmt.Errorf("could not allow writes to %s: %v", path, errno)
+		}
+	}
+
+	if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+		return fmt.Errorf("could not set no_new_privs: %v", err)
+	}
+	if _, _, errno := unix.Syscall(unix.SYS_LANDLOCK_RESTRICT_SELF, uintptr(ruleset), 0, 0); errno != 0 {
+		return fmt.Errorf("could not enforce Landlock ruleset: %v", errno)
+	}
+	return nil
+}
+
+// runSandboxExecImpl is the hidden __sandbox-exec helper: it applies the
+// Landlock rules passed in the environment and execs args in their place.
+// Landlock restricts a single thread, so we stay on it until the exec.
+func runSandboxExecImpl(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no command given")
+	}
+	var paths []string
+	if err := json.Unmarshal([]byte(os.Getenv(sandboxPolicyEnv)), &paths); err != nil {
+		return fmt.Errorf("invalid %s: %v", sandboxPolicyEnv, err)
+	}
+	os.Unsetenv(sandboxPolicyEnv)
+
+	path, err := exec.LookPath(args[0])
+	if err != nil {
+		return err
+	}
+
+	runtime.LockOSThread()
+	if err := landlockRestrict(paths); err != nil {
+		return err
+	}
+	return syscall.Exec(path, args, os.Environ())
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSandboxPolicyAndBwrapArgs(t *testing.T) {
+	repo := newTestRepo(t)
+	os.WriteFile(filepath.Join(repo, ProjectConfigFile), []byte(`
+agents:
+  - name: forge
+sandbox:
+  writable: [../shared-cache]
+  hide: [secrets.txt, ../private, ~/missing]
+`), 0644)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	parent := filepath.Dir(repo)
+	os.Mkdir(filepath.Join(parent, "shared-cache"), 0755)
+	os.Mkdir(filepath.Join(parent, "private"), 0755)
+	os.WriteFile(filepath.Join(repo, "secrets.txt"), []byte("hunter2\n"), 0644)
+
+	forge := CurrentConfig().WorktreePath("forge")
+	policy, err := newSandboxPolicy(CurrentConfig(), forge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	real := func(path string) string {
+		resolved, _ := filepath.EvalSymlinks(path)
+		return resolved
+	}
+	if policy.Parent != real(parent) || policy.Worktree != real(forge) || policy.CommonDir != real(filepath.Join(repo, ".git")) {
+		t.Errorf("policy = %+v", policy)
+	}
+	if len(policy.Writable) != 1 || len(policy.Hide) != 2 {
+		t.Errorf("missing paths should be dropped: %+v", policy)
+	}
+
+	got := strings.Join(bwrapArgs(policy), " ")
+	want := "bwrap --die-with-parent --bind / / --ro-bind " + policy.Parent + " " + policy.Parent +
+		" --bind " + policy.Worktree + " " + policy.Worktree +
+		" --bind " + policy.CommonDir + " " + policy.CommonDir +
+		" --bind " + real(filepath.Join(parent, "shared-cache")) + " " + real(filepath.Join(parent, "shared-cache")) +
+		" --ro-bind /dev/null " + real(filepath.Join(repo, "secrets.txt")) +
+		" --tmpfs " + real(filepath.Join(parent, "private")) + " --"
+	if got != want {
+		t.Errorf("bwrap args:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestLandlockKeepsSiblingsReadOnly(t *testing.T) {
+	if landlockABI() < 1 {
+		t.Skip("Requires Landlock")
+	}
+
+	repo := newTestRepo(t)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	cfg := CurrentConfig()
+	forge, axiom := cfg.WorktreePath("forge"), cfg.WorktreePath("axiom")
+	policy, err := newSandboxPolicy(cfg, forge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, _ := json.Marshal(landlockWritable(policy))
+
+	// Landlock is applied by the __sandbox-exec helper in a real binary
+	binary := filepath.Join(t.TempDir(), "agenter")
+	if output, err := exec.Command("go", "build", "-o", binary, ".").CombinedOutput(); err != nil {
+		t.Fatalf("Could not build agenter: %v\n%s", err, output)
+	}
+
+	tests := []struct {
+		name    string
+		script  string
+		allowed bool
+	}{
+		{"own worktree", "echo x > notes.txt", true},
+		{"commit through shared git dir", "git add notes.txt && git commit -q -m notes", true},
+		{"outside the parent dir", "echo x > \"$HOME/scratch\"", true},
+		{"sibling worktree", "echo x > " + shellQuote(filepath.Join(axiom, "notes.txt")), false},
+		{"main checkout", "echo x > " + shellQuote(filepath.Join(repo, "notes.txt")), false},
+		{"parent dir", "mkdir " + shellQuote(filepath.Join(filepath.Dir(repo), "new")), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := exec.Command(binary, "__sandbox-exec", "sh", "-c", tt.script)
+			cmd.Dir = forge
+			cmd.Env = append(os.Environ(), sandboxPolicyEnv+"="+string(rules))
+			output, err := cmd.CombinedOutput()
+			if tt.allowed && err != nil {
+				t.Errorf("should be allowed: %v\n%s", err, output)
+			}
+			if !tt.allowed && err == nil {
+				t.Error("should be denied")
+			}
+		})
+	}
+}
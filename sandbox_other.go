@@ -0,0 +1,18 @@
+//go:build !linux
+
+package main
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// sandboxCommand is only implemented on Linux.
+func sandboxCommand(cmd *exec.Cmd, policy *sandboxPolicy) error {
+	return fmt.Errorf("--sandbox is only supported on Linux")
+}
+
+// runSandboxExecImpl is only implemented on Linux.
+func runSandboxExecImpl(args []string) error {
+	return fmt.Errorf("sandboxing is only supported on Linux")
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Session logs start with a fixed-width header. The end and exit fields
+// are padded so they can be filled in place once the session finishes,
+// leaving the transcript that follows untouched.
+const (
+	sessionHeaderEnd = "# ---"
+	sessionEndWidth  = 25
+	sessionExitWidth = 20
+	sessionIDLayout  = "20060102-150405"
+)
+
+// sessionMeta is the header of one recorded session.
+type sessionMeta struct {
+	ID      string
+	Project string
+	Agent   string
+	Branch  string
+	Commit  string
+	Start   time.Time
+	End     time.Time
+	Exit    string
+	Path    string
+}
+
+// sessionsDir is where agent's recordings for cfg's project are kept.
+// Recordings are keyed by project name, so it refuses a name that
+// another registered checkout also uses rather than mix their sessions.
+func sessionsDir(cfg *ProjectConfig, agent string) (string, error) {
+	g, err := LoadGlobalConfig()
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range g.ProjectsNamed(cfg.Name) {
+		if entry.Path != cfg.Root {
+			return "", fmt.Errorf("project name %s is also used by %s; set a different name in %s so their sessions stay apart",
+				cfg.Name, FormatPath(entry.Path), ProjectConfigFile)
+		}
+	}
+	return filepath.Join(agenterDir(), "sessions", cfg.Name, agent), nil
+}
+
+// formatSessionHeader renders meta as the fixed-width header block.
+func formatSessionHeader(meta sessionMeta) string {
+	end := "-"
+	if !meta.End.IsZero() {
+		end = meta.End.Format(time.RFC3339)
+	}
+	exit := meta.Exit
+	if exit == "" {
+		exit = "-"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# agenter session %s\n", meta.ID)
+	fmt.Fprintf(&b, "# project: %s\n", meta.Project)
+	fmt.Fprintf(&b, "# agent:   %s\n", meta.Agent)
+	fmt.Fprintf(&b, "# branch:  %s\n", meta.Branch)
+	fmt.Fprintf(&b, "# commit:  %s\n", meta.Commit)
+	fmt.Fprintf(&b, "# start:   %s\n", meta.Start.Format(time.RFC3339))
+	fmt.Fprintf(&b, "# end:     %-*s\n", sessionEndWidth, end)
+	fmt.Fprintf(&b, "# exit:    %-*s\n", sessionExitWidth, truncate(exit, sessionExitWidth))
+	fmt.Fprintf(&b, "%s\n", sessionHeaderEnd)
+	return b.String()
+}
+
+// truncate shortens s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// parseSessionHeader reads the header block from the start of a log.
+func parseSessionHeader(r io.Reader) (sessionMeta, error) {
+	var meta sessionMeta
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == sessionHeaderEnd {
+			return meta, nil
+		}
+		if id, ok := strings.CutPrefix(line, "# agenter session "); ok {
+			meta.ID = id
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "# "), ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch key {
+		case "project":
+			meta.Project = value
+		case "agent":
+			meta.Agent = value
+		case "branch":
+			meta.Branch = value
+		case "commit":
+			meta.Commit = value
+		case "start":
+			meta.Start, _ = time.Parse(time.RFC3339, value)
+		case "end":
+			meta.End, _ = time.Parse(time.RFC3339, value)
+		case "exit":
+			if value != "-" {
+				meta.Exit = value
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return meta, err
+	}
+	return meta, fmt.Errorf("not a session log")
+}
+
+// startSession creates the log for a new session of agent in dir and
+// writes its header.
+func startSession(cfg *ProjectConfig, agent string, dir string) (*sessionMeta, error) {
+	now := time.Now()
+	meta := &sessionMeta{
+		ID:      now.Format(sessionIDLayout),
+		Project: cfg.Name,
+		Agent:   agent,
+		Start:   now.Truncate(time.Second),
+	}
+	meta.Branch, _ = gitOutput(dir, "rev-parse", "--abbrev-ref", "HEAD")
+	meta.Commit, _ = gitOutput(dir, "rev-parse", "--short", "HEAD")
+
+	logDir, err := sessionsDir(cfg, agent)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(logDir, 0755); err != nil {
+		return nil, err
+	}
+	meta.Path = filepath.Join(logDir, meta.ID+".log")
+
+	// Two launches in the same second get distinct logs
+	for i := 2; ; i++ {
+		f, err := os.OpenFile(meta.Path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if os.IsExist(err) {
+			meta.Path = filepath.Join(logDir, fmt.Sprintf("%s-%d.log", now.Format(sessionIDLayout), i))
+			meta.ID = strings.TrimSuffix(filepath.Base(meta.Path), ".log")
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		_, err = f.WriteString(formatSessionHeader(*meta))
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return meta, err
+	}
+}
+
+// finishSession records the end time and exit status in the header.
+func finishSession(meta *sessionMeta, exit string) error {
+	meta.End = time.Now().Truncate(time.Second)
+	meta.Exit = exit
+
+	f, err := os.OpenFile(meta.Path, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.WriteAt([]byte(formatSessionHeader(*meta)), 0)
+	return err
+}
+
+// appendSessionNote adds a line from agenter after the transcript.
+func appendSessionNote(meta *sessionMeta, note string) error {
+	f, err := os.OpenFile(meta.Path, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "\n# agenter: %s\n", note)
+	return err
+}
+
+// exitStatus describes how a child process ended for the session log.
+func exitStatus(err error) string {
+	if err == nil {
+		return "0"
+	}
+	if _, ok := err.(*timeoutError); ok {
+		return "timeout"
+	}
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		if exitErr.ExitCode() >= 0 {
+			return fmt.Sprint(exitErr.ExitCode())
+		}
+		return exitErr.String()
+	}
+	return "error"
+}
+
+// recordCommand wraps cmd so its terminal output is also appended to
+// logPath, using script(1) to keep the session interactive. It returns
+// false when script isn't available.
+func recordCommand(cmd *exec.Cmd, logPath string) bool {
+	scriptPath, err := exec.LookPath("script")
+	if err != nil {
+		return false
+	}
+
+	var args []string
+	switch runtime.GOOS {
+	case "darwin", "freebsd", "openbsd", "netbsd":
+		args = append([]string{"script", "-q", "-a", logPath}, cmd.Args...)
+	default:
+		quoted := make([]string, len(cmd.Args))
+		for i, arg := range cmd.Args {
+			quoted[i] = shellQuote(arg)
+		}
+		args = []string{"script", "-q", "-e", "-f", "-a", "-c", strings.Join(quoted, " "), logPath}
+	}
+
+	cmd.Path = scriptPath
+	cmd.Args = args
+	return true
+}
+
+// listSessions returns agent's sessions for cfg's project, newest first.
+func listSessions(cfg *ProjectConfig, agent string) ([]sessionMeta, error) {
+	dir, err := sessionsDir(cfg, agent)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sessions []sessionMeta
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".log") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		meta, err := parseSessionHeader(f)
+		f.Close()
+		if err != nil {
+			LogDebug("Skipping %s: %v", path, err)
+			continue
+		}
+		meta.Path = path
+		sessions = append(sessions, meta)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].ID > sessions[j].ID
+	})
+	return sessions, nil
+}
+
+// sessionsProject picks the project to browse: --project if given,
+// otherwise the one we're in.
+func sessionsProject(project string) (*ProjectConfig, error) {
+	if project != "" {
+		return loadProjectByRef(project)
+	}
+	cfg := CurrentConfig()
+	if cfg.Root == "" {
+		return nil, fmt.Errorf("not in a git repository. Use --project to pick one")
+	}
+	return cfg, nil
+}
+
+// runSessionsListImpl lists recorded sessions for one agent or all of them.
+func runSessionsListImpl(agent string, project string) error {
+	cfg, err := sessionsProject(project)
+	if err != nil {
+		return err
+	}
+
+	agents := cfg.AgentNames()
+	if agent != "" {
+		if cfg.Agent(agent) == nil {
+			return fmt.Errorf("unknown agent name: %s (must be %s)", agent, describeAgentNames(agents))
+		}
+		agents = []string{agent}
+	}
+
+	var sessions []sessionMeta
+	for _, name := range agents {
+		found, err := listSessions(cfg, name)
+		if err != nil {
+			return err
+		}
+		sessions = append(sessions, found...)
+	}
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return sessions[i].Start.After(sessions[j].Start)
+	})
+
+	PrintHeader(fmt.Sprintf("Sessions: %s", cfg.Name))
+	if len(sessions) == 0 {
+		PrintInfo("No recorded sessions")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "AGENT\tID\tBRANCH\tCOMMIT\tSTARTED\tDURATION\tEXIT")
+	for _, s := range sessions {
+		duration, exit := "running", s.Exit
+		if !s.End.IsZero() {
+			duration = s.End.Sub(s.Start).String()
+		}
+		if exit == "" {
+			exit = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			s.Agent, s.ID, s.Branch, s.Commit, FormatAge(s.Start), duration, exit)
+	}
+	return w.Flush()
+}
+
+var ansiEscapes = regexp.MustCompile(`\x1b\[[0-9;?]*[ -/]*[@-~]|\x1b\][^\x07]*(\x07|\x1b\\)|\x1b[()][0-9A-Za-z]|\r`)
+
+// runSessionsShowImpl prints one session, the latest if id is empty.
+// With plain, terminal escape sequences are stripped so it can be read
+// in a pager or grepped.
+func runSessionsShowImpl(agent string, id string, project string, plain bool) error {
+	cfg, err := sessionsProject(project)
+	if err != nil {
+		return err
+	}
+	if cfg.Agent(agent) == nil {
+		return fmt.Errorf("unknown agent name: %s (must be %s)", agent, describeAgentNames(cfg.AgentNames()))
+	}
+
+	sessions, err := listSessions(cfg, agent)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return fmt.Errorf("no recorded sessions for %s", agent)
+	}
+
+	session := sessions[0]
+	if id != "" {
+		found := false
+		for _, s := range sessions {
+			if s.ID == id {
+				session, found = s, true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("no session %s for %s. Run 'agenter sessions list %s'", id, agent, agent)
+		}
+	}
+
+	data, err := os.ReadFile(session.Path)
+	if err != nil {
+		return err
+	}
+	if plain {
+		data = ansiEscapes.ReplaceAll(data, nil)
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
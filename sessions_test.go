@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSessionHeaderRoundTrip(t *testing.T) {
+	start := time.Date(2026, 3, 4, 5, 6, 7, 0, time.UTC)
+	meta := sessionMeta{
+		ID:      "20260304-050607",
+		Project: "app",
+		Agent:   "forge",
+		Branch:  "forge-worktree-fix",
+		Commit:  "abc1234",
+		Start:   start,
+	}
+
+	open := formatSessionHeader(meta)
+	meta.End = start.Add(90 * time.Minute)
+	meta.Exit = "130"
+	closed := formatSessionHeader(meta)
+
+	// Finishing rewrites the header in place, so it must not change size
+	if len(open) != len(closed) {
+		t.Fatalf("header length changed from %d to %d", len(open), len(closed))
+	}
+
+	got, err := parseSessionHeader(strings.NewReader(closed + "transcript\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != meta.ID || got.Branch != meta.Branch || got.Commit != meta.Commit || got.Exit != "130" {
+		t.Errorf("parsed %+v", got)
+	}
+	if !got.Start.Equal(start) || !got.End.Equal(meta.End) {
+		t.Errorf("times = %v - %v", got.Start, got.End)
+	}
+
+	running, _ := parseSessionHeader(strings.NewReader(open))
+	if !running.End.IsZero() || running.Exit != "" {
+		t.Errorf("unfinished session parsed as %+v", running)
+	}
+}
+
+func TestLaunchRecordsSession(t *testing.T) {
+	if _, err := exec.LookPath("script"); err != nil {
+		t.Skip("Requires script")
+	}
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	installFakeClaude(t)
+	os.Chdir(repo)
+
+	if err := runLaunchImpl("forge", launchOptions{Args: []string{"hello", "from claude"}}); err != nil {
+		t.Fatalf("launch failed: %v", err)
+	}
+
+	// Arguments survive script's shell command line intact
+	args, _ := os.ReadFile(filepath.Join(os.Getenv("HOME"), "launched-args"))
+	if got := strings.TrimSpace(string(args)); got != "hello from claude" {
+		t.Errorf("claude args = %q", got)
+	}
+
+	sessions, err := listSessions(CurrentConfig(), "forge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 1 {
+		t.Fatalf("expected one session, got %d", len(sessions))
+	}
+	s := sessions[0]
+	if s.Branch != "forge-worktree" || s.Commit == "" || s.Exit != "0" || s.End.IsZero() {
+		t.Errorf("unexpected session %+v", s)
+	}
+	if want := filepath.Join(os.Getenv("HOME"), ".agenter", "sessions", "project", "forge"); filepath.Dir(s.Path) != want {
+		t.Errorf("session stored at %s, want it in %s", s.Path, want)
+	}
+
+	if err := runLaunchImpl("forge", launchOptions{NoRecord: true}); err != nil {
+		t.Fatal(err)
+	}
+	if sessions, _ := listSessions(CurrentConfig(), "forge"); len(sessions) != 1 {
+		t.Errorf("--no-record still wrote a session")
+	}
+
+	// Another checkout with the same name would mix their sessions
+	g, _ := LoadGlobalConfig()
+	g.Upsert(ProjectEntry{Name: "project", Path: filepath.Join(t.TempDir(), "project")})
+	if err := g.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listSessions(CurrentConfig(), "forge"); err == nil || !strings.Contains(err.Error(), "is also used by") {
+		t.Errorf("expected a name clash error, got %v", err)
+	}
+}
+
+func TestAnsiEscapesAreStripped(t *testing.T) {
+	in := "\x1b[1;31mred\x1b[0m\r\n\x1b]0;title\x07done\x1b[?25h"
+	if got := ansiEscapes.ReplaceAllString(in, ""); got != "red\ndone" {
+		t.Errorf("stripped to %q", got)
+	}
+}
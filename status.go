@@ -0,0 +1,283 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// agentStatus is a health snapshot of one agent's worktree.
+type agentStatus struct {
+	Agent  string
+	Path   string
+	Branch string
+	OnBase bool
+
+	Staged     int
+	Modified   int
+	Untracked  int
+	Conflicted int
+
+	Upstream       string
+	UpstreamAhead  int
+	UpstreamBehind int
+
+	MainRef    string
+	MainAhead  int
+	MainBehind int
+
+	LastCommit time.Time
+	Tool       string
+	Processes  []int
+	Lock       *agentLock
+	Supervisor *supervisorState
+
+	// Problems make the agent unhealthy; warnings are worth a look.
+	Problems []string
+	Warnings []string
+}
+
+// Healthy reports whether nothing is wrong with the agent's worktree.
+func (s *agentStatus) Healthy() bool {
+	return len(s.Problems) == 0
+}
+
+// collectAgentStatus inspects agent's worktree in cfg's project.
+func collectAgentStatus(cfg *ProjectConfig, agent *AgentConfig) agentStatus {
+	status := agentStatus{Agent: agent.Name}
+
+	path, err := findAgentWorktree(cfg, agent.Name)
+	if err != nil {
+		status.Problems = append(status.Problems, err.Error())
+		return status
+	}
+	status.Path = path
+
+	branch, err := gitOutput(path, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		status.Problems = append(status.Problems, fmt.Sprintf("could not read HEAD: %v", err))
+		return status
+	}
+	status.Branch = branch
+	status.OnBase = branch == agent.Branch
+	_, onTopic := cfg.TopicOf(agent, branch)
+
+	switch {
+	case branch == "HEAD":
+		status.Problems = append(status.Problems, "HEAD is detached")
+	case !status.OnBase && !onTopic:
+		status.Problems = append(status.Problems, fmt.Sprintf("on %s, which isn't %s's base or topic branch", branch, agent.Name))
+	}
+
+	if output, err := gitOutput(path, "status", "--porcelain"); err == nil {
+		status.countChanges(output)
+	}
+	if status.Conflicted > 0 {
+		status.Problems = append(status.Problems, fmt.Sprintf("%d conflicted files", status.Conflicted))
+	} else if status.Staged+status.Modified+status.Untracked > 0 {
+		status.Warnings = append(status.Warnings, "uncommitted changes")
+	}
+
+	if upstream, err := gitOutput(path, "rev-parse", "--abbrev-ref", "@{upstream}"); err == nil {
+		status.Upstream = upstream
+		status.UpstreamAhead, status.UpstreamBehind, _ = aheadBehind(path, "HEAD", upstream)
+		if status.UpstreamAhead > 0 && !status.OnBase {
+			status.Warnings = append(status.Warnings, fmt.Sprintf("%d unpushed commits", status.UpstreamAhead))
+		}
+	}
+
+	if ref := integrationRef(path, integrationBranch(cfg, path)); refExists(path, ref) {
+		status.MainRef = ref
+		status.MainAhead, status.MainBehind, _ = aheadBehind(path, "HEAD", ref)
+	}
+	if status.MainBehind > 0 && status.OnBase {
+		status.Warnings = append(status.Warnings, fmt.Sprintf("base is %d commits behind %s", status.MainBehind, status.MainRef))
+	}
+
+	if output, err := gitOutput(path, "log", "-1", "--format=%ct"); err == nil {
+		if secs, err := strconv.ParseInt(output, 10, 64); err == nil {
+			status.LastCommit = time.Unix(secs, 0)
+		}
+	}
+
+	// The lock tells us about sessions started by agenter; the process
+	// scan catches the agent's tool started by hand in the same worktree
+	lock, err := readAgentLock(path)
+	switch {
+	case err != nil:
+		status.Warnings = append(status.Warnings, err.Error())
+	case lock != nil && lock.Alive():
+		status.Lock = lock
+	case lock != nil:
+		status.Warnings = append(status.Warnings, fmt.Sprintf("stale lock from a crashed session (%s)", lock.Describe()))
+	}
+
+	supervisor, err := readSupervisorState(path)
+	switch {
+	case err != nil:
+		status.Warnings = append(status.Warnings, err.Error())
+	case supervisor == nil:
+	case supervisor.Active() && !supervisor.Alive():
+		status.Warnings = append(status.Warnings, fmt.Sprintf("supervisor pid %d died while %s", supervisor.PID, supervisor.State))
+	case supervisor.State == supervisorFailed:
+		status.Warnings = append(status.Warnings, fmt.Sprintf("supervisor gave up after %d restarts", supervisor.Restarts))
+	}
+	status.Supervisor = supervisor
+
+	backend, err := cfg.Backend(agent)
+	if err != nil {
+		status.Problems = append(status.Problems, err.Error())
+		return status
+	}
+	status.Tool = backend.ProcessName()
+	status.Processes = findProcessesIn(path, status.Tool)
+	if len(status.Processes) > 1 {
+		status.Problems = append(status.Problems, fmt.Sprintf("%d %s sessions share this worktree", len(status.Processes), backend.Title()))
+	} else if len(status.Processes) == 1 && status.Lock == nil {
+		status.Warnings = append(status.Warnings, fmt.Sprintf("%s is running without 'agenter launch'", backend.Title()))
+	}
+
+	return status
+}
+
+// countChanges tallies 'git status --porcelain' lines by kind.
+func (s *agentStatus) countChanges(output string) {
+	for _, line := range strings.Split(output, "\n") {
+		if len(line) < 2 {
+			continue
+		}
+		x, y := line[0], line[1]
+		switch {
+		case x == '?' && y == '?':
+			s.Untracked++
+		case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+			s.Conflicted++
+		default:
+			if x != ' ' {
+				s.Staged++
+			}
+			if y != ' ' {
+				s.Modified++
+			}
+		}
+	}
+}
+
+// findProcessesIn returns PIDs of processes named like name whose working
+// directory is dir. It relies on /proc, so it finds nothing on macOS.
+func findProcessesIn(dir string, name string) []int {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil
+	}
+
+	var pids []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+		cwd, err := os.Readlink(filepath.Join("/proc", entry.Name(), "cwd"))
+		if err != nil || cwd != dir {
+			continue
+		}
+		cmdline, err := os.ReadFile(filepath.Join("/proc", entry.Name(), "cmdline"))
+		if err != nil {
+			continue
+		}
+		// Script-based tools show up as their interpreter, so look at
+		// the script name too
+		argv := strings.SplitN(string(cmdline), "\x00", 3)
+		for _, arg := range argv[:min(len(argv), 2)] {
+			if strings.Contains(filepath.Base(arg), name) {
+				pids = append(pids, pid)
+				break
+			}
+		}
+	}
+	return pids
+}
+
+// printAgentStatus writes one agent's block of the status report.
+func printAgentStatus(s agentStatus) {
+	fmt.Printf("%s  %s\n", PrintAgent(s.Agent), FormatPath(s.Path))
+
+	if s.Branch != "" {
+		kind := "topic"
+		if s.OnBase {
+			kind = "base"
+		}
+		fmt.Printf("  branch:   %s (%s)\n", s.Branch, kind)
+
+		changes := "clean"
+		if s.Staged+s.Modified+s.Untracked+s.Conflicted > 0 {
+			changes = fmt.Sprintf("%d staged, %d modified, %d untracked, %d conflicted",
+				s.Staged, s.Modified, s.Untracked, s.Conflicted)
+		}
+		fmt.Printf("  changes:  %s\n", changes)
+
+		if s.Upstream != "" {
+			fmt.Printf("  upstream: %s ↑%d ↓%d\n", s.Upstream, s.UpstreamAhead, s.UpstreamBehind)
+		} else {
+			fmt.Printf("  upstream: none\n")
+		}
+		if s.MainRef != "" {
+			fmt.Printf("  main:     %s ↑%d ↓%d\n", s.MainRef, s.MainAhead, s.MainBehind)
+		}
+		if !s.LastCommit.IsZero() {
+			fmt.Printf("  commit:   %s\n", FormatAge(s.LastCommit))
+		}
+
+		session := "not running"
+		if s.Lock != nil {
+			session = fmt.Sprintf("running (%s, started %s)", s.Lock.Describe(), FormatAge(s.Lock.Started))
+		} else if len(s.Processes) > 0 {
+			pids := make([]string, len(s.Processes))
+			for i, pid := range s.Processes {
+				pids[i] = strconv.Itoa(pid)
+			}
+			session = fmt.Sprintf("running (pid %s)", strings.Join(pids, ", "))
+		}
+		fmt.Printf("  %-9s %s\n", s.Tool+":", session)
+		if s.Supervisor != nil {
+			fmt.Printf("  supervisor: %s\n", s.Supervisor.Describe())
+		}
+	}
+
+	for _, problem := range s.Problems {
+		PrintError("%s", problem)
+	}
+	for _, warning := range s.Warnings {
+		PrintWarning("%s", warning)
+	}
+	fmt.Println()
+}
+
+// runStatusImpl implements the status command. It returns an error when
+// any agent is unhealthy so scripts can rely on the exit code.
+func runStatusImpl() error {
+	cfg := CurrentConfig()
+	if cfg.Root == "" {
+		return fmt.Errorf("not in a git repository")
+	}
+
+	PrintHeader(fmt.Sprintf("Agent Status: %s", cfg.Name))
+
+	unhealthy := 0
+	for i := range cfg.Agents {
+		status := collectAgentStatus(cfg, &cfg.Agents[i])
+		printAgentStatus(status)
+		if !status.Healthy() {
+			unhealthy++
+		}
+	}
+
+	if unhealthy > 0 {
+		return fmt.Errorf("%d of %d agents unhealthy", unhealthy, len(cfg.Agents))
+	}
+	PrintSuccess("All %d agents healthy", len(cfg.Agents))
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCollectAgentStatusReportsWorktreeHealth(t *testing.T) {
+	repo := newTestRepo(t)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	cfg := CurrentConfig()
+
+	// Move main ahead, leave forge dirty, put axiom on a stray branch
+	// and remove jarvis entirely
+	os.WriteFile(filepath.Join(repo, "main.txt"), []byte("x\n"), 0644)
+	runTestGit(t, repo, "add", ".")
+	runTestGit(t, repo, "commit", "-q", "-m", "main moves on")
+
+	forge := cfg.WorktreePath("forge")
+	os.WriteFile(filepath.Join(forge, "README.md"), []byte("changed\n"), 0644)
+	os.WriteFile(filepath.Join(forge, "new.txt"), []byte("new\n"), 0644)
+
+	runTestGit(t, cfg.WorktreePath("axiom"), "checkout", "-q", "-b", "random")
+	runTestGit(t, repo, "worktree", "remove", cfg.WorktreePath("jarvis"))
+
+	forgeStatus := collectAgentStatus(cfg, cfg.Agent("forge"))
+	if !forgeStatus.Healthy() {
+		t.Errorf("forge should be healthy, got problems %v", forgeStatus.Problems)
+	}
+	if !forgeStatus.OnBase || forgeStatus.Branch != "forge-worktree" {
+		t.Errorf("forge branch = %q (base %v)", forgeStatus.Branch, forgeStatus.OnBase)
+	}
+	if forgeStatus.Modified != 1 || forgeStatus.Untracked != 1 {
+		t.Errorf("forge changes = %d modified, %d untracked", forgeStatus.Modified, forgeStatus.Untracked)
+	}
+	if forgeStatus.MainRef != "main" || forgeStatus.MainBehind != 1 {
+		t.Errorf("forge vs main = %s ↓%d", forgeStatus.MainRef, forgeStatus.MainBehind)
+	}
+	if len(forgeStatus.Warnings) != 2 {
+		t.Errorf("expected dirty and behind warnings, got %v", forgeStatus.Warnings)
+	}
+
+	if status := collectAgentStatus(cfg, cfg.Agent("axiom")); status.Healthy() {
+		t.Error("axiom on a stray branch should be unhealthy")
+	}
+	if status := collectAgentStatus(cfg, cfg.Agent("jarvis")); status.Healthy() {
+		t.Error("jarvis without a worktree should be unhealthy")
+	}
+
+	if err := runStatusImpl(); err == nil {
+		t.Error("expected status to fail with unhealthy agents")
+	}
+}
+
+func TestCountChangesByKind(t *testing.T) {
+	var s agentStatus
+	s.countChanges("M  staged.go\n M modified.go\nMM both.go\n?? new.go\nUU conflict.go\nAA added.go")
+
+	if s.Staged != 2 || s.Modified != 2 || s.Untracked != 1 || s.Conflicted != 2 {
+		t.Errorf("got staged=%d modified=%d untracked=%d conflicted=%d",
+			s.Staged, s.Modified, s.Untracked, s.Conflicted)
+	}
+}
@@ -0,0 +1,240 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+)
+
+// supervisorStateFile sits next to the agent lock in the worktree's git
+// dir, where 'agenter status' can find it.
+const supervisorStateFile = "agenter-supervisor.json"
+
+// Supervisor states. Running and backoff mean the supervisor should
+// still be alive.
+const (
+	supervisorRunning  = "running"
+	supervisorBackoff  = "backoff"
+	supervisorFinished = "finished"
+	supervisorStopped  = "stopped"
+	supervisorFailed   = "failed"
+)
+
+// superviseOptions are the command-line choices for 'agenter supervise'.
+type superviseOptions struct {
+	runOptions
+	// MaxRestarts is how many times a failed run is retried; negative
+	// means forever.
+	MaxRestarts int
+	// Backoff is the wait before the first restart. It doubles after each
+	// failure up to MaxBackoff, and resets once a run lasts MaxBackoff.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+}
+
+// supervisorState is what a supervisor last did, for 'agenter status'.
+type supervisorState struct {
+	PID         int       `json:"pid"`
+	Host        string    `json:"host"`
+	Agent       string    `json:"agent"`
+	State       string    `json:"state"`
+	Restarts    int       `json:"restarts"`
+	MaxRestarts int       `json:"max_restarts"`
+	Started     time.Time `json:"started"`
+	LastExit    string    `json:"last_exit,omitempty"`
+	LastExitAt  time.Time `json:"last_exit_at,omitempty"`
+	NextRestart time.Time `json:"next_restart,omitempty"`
+
+	path string
+}
+
+// supervisorStatePath returns the state file location for the worktree
+// at dir.
+func supervisorStatePath(dir string) (string, error) {
+	lockPath, err := agentLockPath(dir)
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(lockPath), supervisorStateFile), nil
+}
+
+// readSupervisorState returns the state left by the last supervisor of
+// the worktree at dir, or nil when it was never supervised.
+func readSupervisorState(dir string) (*supervisorState, error) {
+	path, err := supervisorStatePath(dir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := &supervisorState{path: path}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("corrupt supervisor state %s: %v", path, err)
+	}
+	return state, nil
+}
+
+// save records the supervisor as being in state. The file is replaced
+// in one go so status never reads half of it.
+func (s *supervisorState) save(state string) {
+	s.State = state
+	if state != supervisorBackoff {
+		s.NextRestart = time.Time{}
+	}
+	data, err := json.Marshal(s)
+	if err == nil {
+		err = writeFileAtomic(s.path, data, 0644)
+	}
+	if err != nil {
+		PrintWarning("Could not save supervisor state: %v", err)
+	}
+}
+
+// Active reports whether the supervisor is meant to be running.
+func (s *supervisorState) Active() bool {
+	return s.State == supervisorRunning || s.State == supervisorBackoff
+}
+
+// Alive reports whether the supervisor process is still running. Ones on
+// another host are assumed alive, as with locks.
+func (s *supervisorState) Alive() bool {
+	if host, _ := os.Hostname(); s.Host != "" && s.Host != host {
+		return true
+	}
+	return processAlive(s.PID)
+}
+
+// Describe summarizes the supervisor for status, e.g. "running, 2/5
+// restarts, last exit 3 4m ago".
+func (s *supervisorState) Describe() string {
+	desc := s.State
+	switch {
+	case s.State == supervisorBackoff && !s.NextRestart.IsZero():
+		desc += fmt.Sprintf(" until %s", s.NextRestart.Format("15:04:05"))
+	case s.Active():
+		desc += fmt.Sprintf(" (pid %d)", s.PID)
+	}
+	if s.MaxRestarts < 0 {
+		desc += fmt.Sprintf(", %d restarts", s.Restarts)
+	} else {
+		desc += fmt.Sprintf(", %d/%d restarts", s.Restarts, s.MaxRestarts)
+	}
+	if s.LastExit != "" {
+		desc += fmt.Sprintf(", last exit %s %s", s.LastExit, FormatAge(s.LastExitAt))
+	}
+	return desc
+}
+
+// backoffDelay is the wait before restarting after the given number of
+// consecutive failures: base, doubling each time, at most max.
+func backoffDelay(base time.Duration, max time.Duration, failures int) time.Duration {
+	delay := base
+	for i := 1; i < failures && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// runSuperviseImpl keeps agent's headless tool running on one prompt,
+// restarting it with backoff when it fails. SIGINT and SIGTERM go to the
+// tool and end supervision once it exits. The last run's failure is
+// returned so its exit code can be passed on.
+func runSuperviseImpl(agent string, opts superviseOptions) error {
+	prompt, err := readPrompt(opts.runOptions)
+	if err != nil {
+		return err
+	}
+	if opts.Backoff <= 0 || opts.MaxBackoff < opts.Backoff {
+		return fmt.Errorf("--backoff must be positive and no more than --max-backoff")
+	}
+
+	dir, err := prepareAgentDir(agent, opts.Project)
+	if err != nil {
+		return err
+	}
+
+	// Held across restarts so nothing else takes the worktree in between
+	lock, err := acquireAgentLock(dir, agent)
+	if err != nil {
+		return err
+	}
+	defer lock.Release()
+
+	path, err := supervisorStatePath(dir)
+	if err != nil {
+		return err
+	}
+	host, _ := os.Hostname()
+	state := &supervisorState{
+		PID:         os.Getpid(),
+		Host:        host,
+		Agent:       agent,
+		MaxRestarts: opts.MaxRestarts,
+		Started:     time.Now().Truncate(time.Second),
+		path:        path,
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	failures := 0
+	for {
+		state.save(supervisorRunning)
+		started := time.Now()
+		err := runHeadless(agent, dir, prompt, opts.runOptions, true)
+		state.LastExit = exitStatus(err)
+		state.LastExitAt = time.Now().Truncate(time.Second)
+
+		select {
+		case sig := <-signals:
+			PrintInfo("Stopped supervising %s on %v", PrintAgent(agent), sig)
+			state.save(supervisorStopped)
+			return err
+		default:
+		}
+
+		if err == nil {
+			PrintSuccess("%s finished", PrintAgent(agent))
+			state.save(supervisorFinished)
+			return nil
+		}
+		if opts.MaxRestarts >= 0 && state.Restarts >= opts.MaxRestarts {
+			PrintWarning("%s failed (%v), giving up after %d restarts", PrintAgent(agent), err, state.Restarts)
+			state.save(supervisorFailed)
+			return err
+		}
+
+		// A run that stayed up a while isn't part of a crash loop
+		if time.Since(started) >= opts.MaxBackoff {
+			failures = 0
+		}
+		failures++
+		delay := backoffDelay(opts.Backoff, opts.MaxBackoff, failures)
+		PrintWarning("%s failed (%v), restarting in %s", PrintAgent(agent), err, delay)
+		state.NextRestart = time.Now().Add(delay).Truncate(time.Second)
+		state.save(supervisorBackoff)
+
+		select {
+		case sig := <-signals:
+			PrintInfo("Stopped supervising %s on %v", PrintAgent(agent), sig)
+			state.save(supervisorStopped)
+			return nil
+		case <-time.After(delay):
+		}
+		state.Restarts++
+	}
+}
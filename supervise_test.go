@@ -0,0 +1,183 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// installFakeFlakyClaude fakes a headless claude that fails until it has
+// been run succeedAfter times, counting runs in $HOME/runs.
+func installFakeFlakyClaude(t *testing.T, succeedAfter int) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "fake-claude")
+	script := "#!/bin/sh\n" +
+		"cat > /dev/null\n" +
+		"echo run >> \"$HOME/runs\"\n" +
+		"[ \"$(wc -l < \"$HOME/runs\")\" -ge " + strconv.Itoa(succeedAfter) + " ] || exit 3\n"
+	if err := os.WriteFile(path, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("AGENTER_CLAUDE", path)
+}
+
+func supervisedRuns(t *testing.T) int {
+	t.Helper()
+	data, _ := os.ReadFile(filepath.Join(os.Getenv("HOME"), "runs"))
+	return strings.Count(string(data), "\n")
+}
+
+func TestBackoffDelay(t *testing.T) {
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{6, 32 * time.Second},
+		{7, time.Minute},
+		{100, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := backoffDelay(time.Second, time.Minute, tt.failures); got != tt.want {
+			t.Errorf("backoffDelay(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestSuperviseRestartsUntilSuccess(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	installFakeFlakyClaude(t, 3)
+	os.Chdir(repo)
+
+	opts := superviseOptions{
+		runOptions:  runOptions{Prompt: "watch the queue"},
+		MaxRestarts: 5,
+		Backoff:     10 * time.Millisecond,
+		MaxBackoff:  40 * time.Millisecond,
+	}
+	if err := runSuperviseImpl("forge", opts); err != nil {
+		t.Fatal(err)
+	}
+	if runs := supervisedRuns(t); runs != 3 {
+		t.Errorf("ran %d times, want 3", runs)
+	}
+
+	state, err := readSupervisorState(CurrentConfig().WorktreePath("forge"))
+	if err != nil || state == nil {
+		t.Fatalf("state = %+v, %v", state, err)
+	}
+	if state.State != supervisorFinished || state.Restarts != 2 || state.LastExit != "0" {
+		t.Errorf("state = %+v", state)
+	}
+	if sessions, _ := listSessions(CurrentConfig(), "forge"); len(sessions) != 3 {
+		t.Errorf("got %d sessions, want one per run", len(sessions))
+	}
+}
+
+func TestSuperviseGivesUp(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	installFakeFlakyClaude(t, 9)
+	os.Chdir(repo)
+
+	opts := superviseOptions{
+		runOptions:  runOptions{Prompt: "watch the queue"},
+		MaxRestarts: 2,
+		Backoff:     10 * time.Millisecond,
+		MaxBackoff:  time.Second,
+	}
+	err := runSuperviseImpl("forge", opts)
+	if code := exitCode(err); code != 3 {
+		t.Errorf("exit code = %d (%v), want 3", code, err)
+	}
+	if runs := supervisedRuns(t); runs != 3 {
+		t.Errorf("ran %d times, want 3", runs)
+	}
+
+	cfg := CurrentConfig()
+	status := collectAgentStatus(cfg, cfg.Agent("forge"))
+	if status.Supervisor == nil || status.Supervisor.State != supervisorFailed {
+		t.Fatalf("supervisor = %+v", status.Supervisor)
+	}
+	if got := status.Supervisor.Describe(); !strings.HasPrefix(got, "failed, 2/2 restarts, last exit 3") {
+		t.Errorf("described as %q", got)
+	}
+	if !strings.Contains(strings.Join(status.Warnings, "\n"), "gave up after 2 restarts") {
+		t.Errorf("warnings = %v", status.Warnings)
+	}
+
+	// A supervisor that died mid-run shows up too
+	state, _ := readSupervisorState(cfg.WorktreePath("forge"))
+	state.PID = deadPID(t)
+	state.save(supervisorRunning)
+	status = collectAgentStatus(cfg, cfg.Agent("forge"))
+	if !strings.Contains(strings.Join(status.Warnings, "\n"), "died while running") {
+		t.Errorf("warnings = %v", status.Warnings)
+	}
+}
+
+func TestSuperviseForwardsSignals(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	home := os.Getenv("HOME")
+	fake := filepath.Join(t.TempDir(), "fake-claude")
+	os.WriteFile(fake, []byte("#!/bin/sh\n"+
+		"cat > /dev/null\n"+
+		"trap 'echo $$ > \"$HOME/terminated\"; exit 143' TERM\n"+
+		"echo run >> \"$HOME/runs\"\n"+
+		"while :; do sleep 0.1; done\n"), 0755)
+	t.Setenv("AGENTER_CLAUDE", fake)
+	os.Chdir(repo)
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			time.Sleep(50 * time.Millisecond)
+			if supervisedRuns(t) > 0 {
+				syscall.Kill(os.Getpid(), syscall.SIGTERM)
+				return
+			}
+		}
+	}()
+
+	opts := superviseOptions{
+		runOptions:  runOptions{Prompt: "watch the queue"},
+		MaxRestarts: 5,
+		Backoff:     10 * time.Millisecond,
+		MaxBackoff:  time.Second,
+	}
+	err := runSuperviseImpl("forge", opts)
+	if code := exitCode(err); code != 143 {
+		t.Errorf("exit code = %d (%v), want 143", code, err)
+	}
+	if _, err := os.Stat(filepath.Join(home, "terminated")); err != nil {
+		t.Error("SIGTERM never reached the agent")
+	}
+	if runs := supervisedRuns(t); runs != 1 {
+		t.Errorf("ran %d times, want no restart after SIGTERM", runs)
+	}
+	if state, _ := readSupervisorState(CurrentConfig().WorktreePath("forge")); state == nil || state.State != supervisorStopped {
+		t.Errorf("state = %+v", state)
+	}
+}
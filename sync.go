@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+// syncResult is how one agent's base branch fared in 'agenter sync'.
+type syncResult struct {
+	Agent  string
+	Branch string
+	// Outcome is "updated", "up to date", "skipped" or "failed".
+	Outcome string
+	Detail  string
+}
+
+// syncAgent brings agent's base branch in its worktree at path up to
+// date with ref. Agents on a topic branch or with uncommitted changes are
+// skipped, and a conflicted update is backed out so the worktree is left
+// as it was.
+func syncAgent(agent *AgentConfig, path string, ref string, strategy string) syncResult {
+	result := syncResult{Agent: agent.Name, Branch: agent.Branch}
+	skip := func(format string, args ...interface{}) syncResult {
+		result.Outcome, result.Detail = "skipped", fmt.Sprintf(format, args...)
+		return result
+	}
+	fail := func(format string, args ...interface{}) syncResult {
+		result.Outcome, result.Detail = "failed", fmt.Sprintf(format, args...)
+		return result
+	}
+
+	if path == "" {
+		return skip("no worktree")
+	}
+	branch, err := gitOutput(path, "rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return fail("could not read HEAD: %v", err)
+	}
+	if branch != agent.Branch {
+		result.Branch = branch
+		return skip("on topic branch")
+	}
+	if status, err := gitOutput(path, "status", "--porcelain"); err != nil {
+		return fail("could not check status: %v", err)
+	} else if status != "" {
+		return skip("uncommitted changes")
+	}
+
+	update, err := updateFrom(path, ref, strategy)
+	switch {
+	case len(update.Conflicts) > 0:
+		// 'git merge --abort' or 'git rebase --abort'; reset can't conflict
+		gitOutput(path, strategy, "--abort")
+		return fail("conflicts in %s; run 'agenter worktree next' there to resolve", strings.Join(update.Conflicts, ", "))
+	case err != nil:
+		return fail("%v", err)
+	case update.UpToDate(strategy):
+		result.Outcome = "up to date"
+		return result
+	}
+
+	result.Outcome = "updated"
+	result.Detail = fmt.Sprintf("%d new commits from %s", update.Behind, ref)
+	if strategy == "reset" && update.Ahead > 0 {
+		result.Detail += fmt.Sprintf(", dropped %d (was %s)", update.Ahead, update.OldHead)
+	}
+	return result
+}
+
+// runSyncImpl fetches the integration branch once and then updates every
+// agent's base branch from it, all worktrees at the same time. It fails
+// when any update failed; skipped agents are only reported.
+func runSyncImpl(strategy string) error {
+	cfg := CurrentConfig()
+	if cfg.Root == "" {
+		return fmt.Errorf("not in a git repository")
+	}
+	if strategy == "" {
+		strategy = cfg.UpdateStrategy
+	}
+	if strategy == "" {
+		strategy = "merge"
+	}
+	if err := validateUpdateStrategy(strategy); err != nil {
+		return err
+	}
+
+	paths, err := agentWorktreePaths(cfg)
+	if err != nil {
+		return err
+	}
+	// Every worktree shares the remote-tracking refs, so one fetch does
+	ref := fetchIntegrationBranch(cfg.Root)
+	PrintInfo("Updating base branches from %s (%s)...", ref, strategy)
+
+	results := make([]syncResult, len(cfg.Agents))
+	var wg sync.WaitGroup
+	for i := range cfg.Agents {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			agent := &cfg.Agents[i]
+			results[i] = syncAgent(agent, paths[agent.Name], ref, strategy)
+		}(i)
+	}
+	wg.Wait()
+
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "AGENT\tBRANCH\tRESULT\tDETAIL")
+	failed, skipped := 0, 0
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Agent, r.Branch, r.Outcome, r.Detail)
+		switch r.Outcome {
+		case "failed":
+			failed++
+		case "skipped":
+			skipped++
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Println()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d agents could not be updated", failed, len(results))
+	}
+	if skipped > 0 {
+		PrintWarning("%d agents skipped; run 'agenter worktree next' in their worktrees when they're done", skipped)
+	}
+	PrintSuccess("%d base branches up to date with %s", len(results)-skipped, ref)
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSyncUpdatesCleanBaseBranches(t *testing.T) {
+	repo := newTestRepo(t)
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	runTestGit(t, repo, "init", "-q", "--bare", origin)
+	runTestGit(t, repo, "remote", "add", "origin", origin)
+	runTestGit(t, repo, "push", "-q", "-u", "origin", "main")
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	cfg := CurrentConfig()
+	forge, axiom, jarvis := cfg.WorktreePath("forge"), cfg.WorktreePath("axiom"), cfg.WorktreePath("jarvis")
+
+	// origin moves on through a clone, so only a fetch sees it
+	clone := filepath.Join(t.TempDir(), "clone")
+	runTestGit(t, repo, "clone", "-q", "-b", "main", origin, clone)
+	os.WriteFile(filepath.Join(clone, "README.md"), []byte("upstream\n"), 0644)
+	runTestGit(t, clone, "commit", "-q", "-am", "upstream change")
+	runTestGit(t, clone, "push", "-q", "origin", "main")
+
+	runTestGit(t, axiom, "checkout", "-q", "-b", "axiom-worktree-fix")
+	os.WriteFile(filepath.Join(jarvis, "notes.txt"), []byte("wip\n"), 0644)
+
+	if err := runSyncImpl(""); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(forge, "README.md")); string(data) != "upstream\n" {
+		t.Errorf("forge not updated: README.md = %q", data)
+	}
+	for _, dir := range []string{axiom, jarvis} {
+		if data, _ := os.ReadFile(filepath.Join(dir, "README.md")); string(data) != "hello\n" {
+			t.Errorf("%s should have been skipped, README.md = %q", dir, data)
+		}
+	}
+
+	results := map[string]syncResult{}
+	ref := integrationRef(repo, "main")
+	for i := range cfg.Agents {
+		agent := &cfg.Agents[i]
+		results[agent.Name] = syncAgent(agent, cfg.WorktreePath(agent.Name), ref, "merge")
+	}
+	if r := results["forge"]; r.Outcome != "up to date" {
+		t.Errorf("forge = %+v", r)
+	}
+	if r := results["axiom"]; r.Outcome != "skipped" || r.Branch != "axiom-worktree-fix" {
+		t.Errorf("axiom = %+v", r)
+	}
+	if r := results["jarvis"]; r.Outcome != "skipped" || r.Detail != "uncommitted changes" {
+		t.Errorf("jarvis = %+v", r)
+	}
+}
+
+func TestSyncBacksOutConflicts(t *testing.T) {
+	repo := newTestRepo(t)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	cfg := CurrentConfig()
+	forge := cfg.WorktreePath("forge")
+
+	os.WriteFile(filepath.Join(repo, "README.md"), []byte("main\n"), 0644)
+	runTestGit(t, repo, "commit", "-q", "-am", "main change")
+	os.WriteFile(filepath.Join(forge, "README.md"), []byte("forge\n"), 0644)
+	runTestGit(t, forge, "commit", "-q", "-am", "forge change")
+	head := runTestGit(t, forge, "rev-parse", "HEAD")
+
+	for _, strategy := range []string{"merge", "rebase"} {
+		r := syncAgent(cfg.Agent("forge"), forge, "main", strategy)
+		if r.Outcome != "failed" || !strings.Contains(r.Detail, "conflicts in README.md") {
+			t.Errorf("%s: %+v", strategy, r)
+		}
+		if status := runTestGit(t, forge, "status", "--porcelain"); status != "" {
+			t.Errorf("%s left the worktree dirty:\n%s", strategy, status)
+		}
+		if got := runTestGit(t, forge, "rev-parse", "HEAD"); got != head {
+			t.Errorf("%s moved HEAD", strategy)
+		}
+	}
+
+	if err := runSyncImpl(""); err == nil || !strings.Contains(err.Error(), "1 of 3 agents") {
+		t.Errorf("expected sync to fail for forge, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// teardownPlan is what removing one agent involves.
+type teardownPlan struct {
+	Agent    string
+	Worktree string // empty when the worktree is already gone
+	Branches []string
+	// Blockers are reasons not to remove the agent without --force.
+	Blockers []string
+}
+
+// planTeardown works out what removing agent from cfg's project would
+// delete, and what would be lost doing so.
+func planTeardown(cfg *ProjectConfig, agent *AgentConfig, worktree string) teardownPlan {
+	plan := teardownPlan{Agent: agent.Name, Worktree: worktree}
+
+	if worktree != "" {
+		if lock, err := readAgentLock(worktree); err == nil && lock != nil && lock.Alive() {
+			plan.Blockers = append(plan.Blockers, fmt.Sprintf("session running (%s)", lock.Describe()))
+		}
+		if output, err := gitOutput(worktree, "status", "--porcelain"); err != nil {
+			plan.Blockers = append(plan.Blockers, fmt.Sprintf("could not check for changes: %v", err))
+		} else if output != "" {
+			plan.Blockers = append(plan.Blockers, fmt.Sprintf("%d uncommitted changes", len(strings.Split(output, "\n"))))
+		}
+	}
+
+	// The base branch and every topic branch made from it
+	if refExists(cfg.Root, "refs/heads/"+agent.Branch) {
+		plan.Branches = append(plan.Branches, agent.Branch)
+	}
+	plan.Branches = append(plan.Branches, topicBranches(cfg, agent)...)
+
+	// Commits that are neither on a remote nor on the integration branch
+	// exist only here
+	mainRef := integrationRef(cfg.Root, integrationBranch(cfg, cfg.Root))
+	for _, branch := range plan.Branches {
+		args := []string{"rev-list", "--count", branch, "--not", "--remotes"}
+		if refExists(cfg.Root, mainRef) {
+			args = append(args, mainRef)
+		}
+		count, err := gitOutput(cfg.Root, args...)
+		if err == nil && count != "0" {
+			plan.Blockers = append(plan.Blockers, fmt.Sprintf("%s has %s unpushed commits", branch, count))
+		}
+	}
+	return plan
+}
+
+// runTeardownImpl undoes setup for the project at ref (a name or path):
+// each agent's worktree and branches are removed and the project is
+// dropped from the registry. Nothing is removed while any agent has
+// uncommitted changes, unpushed commits or a running session, unless
+// force is set.
+func runTeardownImpl(ref string, force bool) error {
+	cfg, err := loadProjectByRef(ref)
+	if err != nil {
+		return err
+	}
+	paths, err := agentWorktreePaths(cfg)
+	if err != nil {
+		return err
+	}
+
+	PrintHeader(fmt.Sprintf("Tearing down %s", cfg.Name))
+
+	var plans []teardownPlan
+	blocked := 0
+	for i := range cfg.Agents {
+		agent := &cfg.Agents[i]
+		plan := planTeardown(cfg, agent, paths[agent.Name])
+		plans = append(plans, plan)
+
+		fmt.Printf("%s\n", PrintAgent(agent.Name))
+		if plan.Worktree != "" {
+			fmt.Printf("  worktree: %s\n", FormatPath(plan.Worktree))
+		} else {
+			fmt.Printf("  worktree: none\n")
+		}
+		if len(plan.Branches) > 0 {
+			fmt.Printf("  branches: %s\n", strings.Join(plan.Branches, ", "))
+		} else {
+			fmt.Printf("  branches: none\n")
+		}
+		for _, blocker := range plan.Blockers {
+			if force {
+				PrintWarning("%s", blocker)
+			} else {
+				PrintError("%s", blocker)
+			}
+		}
+		if len(plan.Blockers) > 0 {
+			blocked++
+		}
+		fmt.Println()
+	}
+
+	if blocked > 0 && !force {
+		return fmt.Errorf("%d agents have work that would be lost; push or commit it, or use --force", blocked)
+	}
+
+	failed := 0
+	for _, plan := range plans {
+		if plan.Worktree != "" {
+			args := []string{"worktree", "remove", plan.Worktree}
+			if force {
+				args = append(args, "--force")
+			}
+			if _, err := gitOutput(cfg.Root, args...); err != nil {
+				PrintError("Could not remove %s: %v", FormatPath(plan.Worktree), err)
+				failed++
+				continue
+			}
+			PrintSuccess("Removed %s", FormatPath(plan.Worktree))
+		}
+		for _, branch := range plan.Branches {
+			if _, err := gitOutput(cfg.Root, "branch", "-D", branch); err != nil {
+				PrintError("Could not delete branch %s: %v", branch, err)
+				failed++
+				continue
+			}
+			PrintSuccess("Deleted branch %s", branch)
+		}
+	}
+
+	if _, err := gitOutput(cfg.Root, "worktree", "prune"); err != nil {
+		PrintWarning("Could not prune worktree metadata: %v", err)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d worktrees or branches could not be removed", failed)
+	}
+
+	g, err := LoadGlobalConfig()
+	if err != nil {
+		return err
+	}
+	if g.Remove(cfg.Root) {
+		if err := g.Save(); err != nil {
+			return err
+		}
+		PrintInfo("Removed %s from %s", cfg.Name, FormatPath(globalConfigPath()))
+	}
+	PrintSuccess("Tore down %s", cfg.Name)
+	return nil
+}
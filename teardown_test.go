@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTeardownRefusesToLoseWork(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	runTestGit(t, repo, "init", "-q", "--bare", origin)
+	runTestGit(t, repo, "remote", "add", "origin", origin)
+	runTestGit(t, repo, "push", "-q", "-u", "origin", "main")
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	cfg := CurrentConfig()
+	forge, axiom := cfg.WorktreePath("forge"), cfg.WorktreePath("axiom")
+
+	// forge has a pushed topic, axiom an unpushed one and jarvis is dirty
+	runTestGit(t, forge, "checkout", "-q", "-b", "forge-worktree-done")
+	os.WriteFile(filepath.Join(forge, "done.txt"), []byte("done\n"), 0644)
+	runTestGit(t, forge, "add", ".")
+	runTestGit(t, forge, "commit", "-q", "-m", "done")
+	runTestGit(t, forge, "push", "-q", "origin", "forge-worktree-done")
+
+	runTestGit(t, axiom, "checkout", "-q", "-b", "axiom-worktree-wip")
+	os.WriteFile(filepath.Join(axiom, "wip.txt"), []byte("wip\n"), 0644)
+	runTestGit(t, axiom, "add", ".")
+	runTestGit(t, axiom, "commit", "-q", "-m", "wip")
+
+	jarvis := cfg.WorktreePath("jarvis")
+	os.WriteFile(filepath.Join(jarvis, "README.md"), []byte("edited\n"), 0644)
+
+	if plan := planTeardown(cfg, cfg.Agent("forge"), forge); len(plan.Blockers) != 0 ||
+		strings.Join(plan.Branches, ",") != "forge-worktree,forge-worktree-done" {
+		t.Errorf("forge plan = %+v", plan)
+	}
+	if plan := planTeardown(cfg, cfg.Agent("axiom"), axiom); len(plan.Blockers) != 1 ||
+		plan.Blockers[0] != "axiom-worktree-wip has 1 unpushed commits" {
+		t.Errorf("axiom plan = %+v", plan)
+	}
+	if plan := planTeardown(cfg, cfg.Agent("jarvis"), jarvis); len(plan.Blockers) != 1 ||
+		plan.Blockers[0] != "1 uncommitted changes" {
+		t.Errorf("jarvis plan = %+v", plan)
+	}
+
+	// Nothing is removed while any agent would lose work
+	err := runTeardownImpl(repo, false)
+	if err == nil || !strings.Contains(err.Error(), "2 agents have work") {
+		t.Fatalf("expected teardown to refuse, got %v", err)
+	}
+	for _, dir := range []string{forge, axiom, jarvis} {
+		if _, err := os.Stat(dir); err != nil {
+			t.Errorf("%s removed despite refusal", dir)
+		}
+	}
+
+	os.Chdir(repo)
+	if err := runTeardownImpl(".", true); err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range []string{forge, axiom, jarvis} {
+		if _, err := os.Stat(dir); !os.IsNotExist(err) {
+			t.Errorf("%s still exists", dir)
+		}
+	}
+	if branches := strings.TrimSpace(runTestGit(t, repo, "branch", "--format=%(refname:short)")); branches != "main" {
+		t.Errorf("branches left: %s", branches)
+	}
+	if worktrees := runTestGit(t, repo, "worktree", "list"); strings.Count(worktrees, "\n") != 1 {
+		t.Errorf("worktrees left:\n%s", worktrees)
+	}
+	g, _ := LoadGlobalConfig()
+	if g.Project(repo) != nil {
+		t.Error("project still registered")
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+var tmuxUnsafeChars = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+
+// tmuxSessionName is the tmux session used for cfg's project. tmux
+// treats '.' and ':' specially in targets, so we keep names plain.
+func tmuxSessionName(cfg *ProjectConfig) string {
+	return "agenter-" + tmuxUnsafeChars.ReplaceAllString(cfg.Name, "-")
+}
+
+// tmux runs a tmux subcommand and returns its trimmed output.
+func tmux(args ...string) (string, error) {
+	cmd := exec.Command("tmux", args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("tmux %s: %s", args[0], strings.TrimSpace(string(output)))
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// tmuxHasSession reports whether a session with exactly this name exists.
+func tmuxHasSession(name string) bool {
+	return exec.Command("tmux", "has-session", "-t", "="+name).Run() == nil
+}
+
+// shellQuote quotes s for use in a POSIX shell command line.
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}()<>|&;#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// runUpImpl starts every agent of the current project in one tmux
+// session, a window (or pane) per agent, each running 'agenter launch'
+// in its worktree so the usual directory guard still applies. With
+// sandbox, each launch gets --sandbox.
+func runUpImpl(attach bool, panes bool, sandbox bool) error {
+	cfg := CurrentConfig()
+	if cfg.Root == "" {
+		return fmt.Errorf("not in a git repository")
+	}
+	if _, err := exec.LookPath("tmux"); err != nil {
+		return fmt.Errorf("tmux is not installed")
+	}
+
+	session := tmuxSessionName(cfg)
+	if tmuxHasSession(session) {
+		PrintInfo("Attach with: tmux attach -t %s", session)
+		PrintInfo("Or stop it first with: agenter down")
+		return fmt.Errorf("session %s is already running", session)
+	}
+
+	self, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not find agenter binary: %v", err)
+	}
+
+	PrintHeader(fmt.Sprintf("Starting %s in tmux session %s", cfg.Name, session))
+
+	started := 0
+	for i, agent := range cfg.Agents {
+		PrintStep(i+1, len(cfg.Agents), fmt.Sprintf("Starting %s...", agent.Name))
+
+		path, err := findAgentWorktree(cfg, agent.Name)
+		if err != nil {
+			PrintWarning("Skipping %s: %v", agent.Name, err)
+			continue
+		}
+
+		var args []string
+		switch {
+		case started == 0:
+			args = []string{"new-session", "-d", "-s", session, "-n", agent.Name, "-x", "200", "-y", "50"}
+		case panes:
+			args = []string{"split-window", "-t", session}
+		default:
+			args = []string{"new-window", "-t", session + ":", "-n", agent.Name}
+		}
+		// Drop into a shell in the worktree once the agent exits, so the
+		// pane stays around for a look at what happened
+		launch := fmt.Sprintf("%s launch %s", shellQuote(self), agent.Name)
+		if sandbox {
+			launch += " --sandbox"
+		}
+		launch += "; exec \"${SHELL:-/bin/sh}\""
+		args = append(args, "-P", "-F", "#{pane_id}", "-c", path, "-e", "WHO_AM_I="+agent.Name, launch)
+
+		pane, err := tmux(args...)
+		if err != nil {
+			if started > 0 {
+				tmux("kill-session", "-t", "="+session)
+			}
+			return err
+		}
+		started++
+
+		if panes {
+			tmux("select-pane", "-t", pane, "-T", agent.Name)
+			tmux("select-layout", "-t", session, "tiled")
+		}
+
+		PrintSuccess("Started %s in %s", PrintAgent(agent.Name), FormatPath(path))
+	}
+
+	if started == 0 {
+		return fmt.Errorf("no agent worktrees found. Run 'agenter setup <repository>' first")
+	}
+
+	fmt.Println()
+	if !attach {
+		PrintInfo("Attach with: tmux attach -t %s", session)
+		return nil
+	}
+
+	// Inside tmux, attaching would nest sessions, so switch instead
+	var cmd *exec.Cmd
+	if os.Getenv("TMUX") != "" {
+		cmd = exec.Command("tmux", "switch-client", "-t", "="+session)
+	} else {
+		cmd = exec.Command("tmux", "attach-session", "-t", "="+session)
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// runDownImpl stops the project's tmux session along with every agent in it.
+func runDownImpl() error {
+	cfg := CurrentConfig()
+	if cfg.Root == "" {
+		return fmt.Errorf("not in a git repository")
+	}
+
+	session := tmuxSessionName(cfg)
+	if !tmuxHasSession(session) {
+		PrintInfo("No tmux session running for %s", cfg.Name)
+		return nil
+	}
+
+	if _, err := tmux("kill-session", "-t", "="+session); err != nil {
+		return err
+	}
+	PrintSuccess("Stopped tmux session %s", session)
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShellQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":      "plain",
+		"/usr/bin/x": "/usr/bin/x",
+		"with space": "'with space'",
+		"it's":       `'it'\''s'`,
+		"$HOME":      "'$HOME'",
+		"":           "''",
+	}
+	for in, want := range tests {
+		if got := shellQuote(in); got != want {
+			t.Errorf("shellQuote(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestTmuxSessionNameIsSafe(t *testing.T) {
+	cfg := &ProjectConfig{Name: "my.app:v2"}
+	if got := tmuxSessionName(cfg); got != "agenter-my-app-v2" {
+		t.Errorf("tmuxSessionName = %q", got)
+	}
+}
+
+// TestUpStartsEveryAgentInTmux runs the real binary under a private tmux
+// server with a fake claude that records who it is and where it runs.
+func TestUpStartsEveryAgentInTmux(t *testing.T) {
+	if _, err := exec.LookPath("tmux"); err != nil {
+		t.Skip("Requires tmux")
+	}
+
+	repo := newTestRepo(t)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	cfg := CurrentConfig()
+
+	// Private tmux server, plain shell and a fake claude first on PATH
+	t.Setenv("TMUX_TMPDIR", t.TempDir())
+	t.Setenv("TMUX", "")
+	t.Setenv("SHELL", "/bin/sh")
+	t.Cleanup(func() { exec.Command("tmux", "kill-server").Run() })
+
+	home := os.Getenv("HOME")
+	binDir := t.TempDir()
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	fakeClaude := filepath.Join(binDir, "claude")
+	script := "#!/bin/sh\necho \"$WHO_AM_I $(pwd)\" > \"$HOME/launched-$WHO_AM_I\"\nsleep 60\n"
+	if err := os.WriteFile(fakeClaude, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	// Panes run 'agenter launch', so they need a real agenter binary
+	binary := filepath.Join(t.TempDir(), "agenter")
+	if output, err := exec.Command("go", "build", "-o", binary, ".").CombinedOutput(); err != nil {
+		t.Fatalf("Could not build agenter: %v\n%s", err, output)
+	}
+	cmd := exec.Command(binary, "up", "--detach")
+	cmd.Dir = repo
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("up failed: %v\n%s", err, output)
+	}
+
+	session := tmuxSessionName(cfg)
+	windows, err := tmux("list-windows", "-t", session, "-F", "#{window_name}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if windows != "forge\naxiom\njarvis" {
+		t.Errorf("windows = %q", windows)
+	}
+
+	for _, agent := range cfg.AgentNames() {
+		marker := filepath.Join(home, "launched-"+agent)
+		want := agent + " " + cfg.WorktreePath(agent)
+
+		var got string
+		for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); {
+			if data, err := os.ReadFile(marker); err == nil {
+				got = strings.TrimSpace(string(data))
+				break
+			}
+			time.Sleep(100 * time.Millisecond)
+		}
+		if got != want {
+			t.Errorf("%s launched as %q, want %q", agent, got, want)
+		}
+	}
+
+	if err := runUpImpl(false, false, false); err == nil {
+		t.Error("expected second up to fail while the session is running")
+	}
+
+	if err := runDownImpl(); err != nil {
+		t.Fatal(err)
+	}
+	if tmuxHasSession(session) {
+		t.Error("session still running after down")
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// pullRequest is the GitHub pull request opened from a topic branch.
+type pullRequest struct {
+	Number int    `json:"number"`
+	State  string `json:"state"`
+	URL    string `json:"url"`
+}
+
+// topicInfo describes one agent topic branch, local, on origin or both.
+type topicInfo struct {
+	Agent      string       `json:"agent"`
+	Branch     string       `json:"branch"`
+	Local      bool         `json:"local"`
+	Remote     bool         `json:"remote"`
+	Pushed     bool         `json:"pushed"`
+	Ahead      int          `json:"ahead"`
+	Behind     int          `json:"behind"`
+	LastCommit time.Time    `json:"last_commit"`
+	PR         *pullRequest `json:"pr,omitempty"`
+}
+
+// listPullRequests maps head branch names to their most recent pull
+// request. It returns nil when gh is missing or can't tell.
+func listPullRequests(dir string) map[string]*pullRequest {
+	if _, err := exec.LookPath("gh"); err != nil {
+		LogDebug("gh not found, not listing pull requests")
+		return nil
+	}
+	output, err := ghOutput(dir, "pr", "list", "--state", "all", "--limit", "200",
+		"--json", "number,state,url,headRefName")
+	if err != nil {
+		PrintWarning("Could not list pull requests: %v", err)
+		return nil
+	}
+	var prs []struct {
+		pullRequest
+		HeadRefName string `json:"headRefName"`
+	}
+	if err := json.Unmarshal([]byte(output), &prs); err != nil {
+		PrintWarning("Could not parse gh output: %v", err)
+		return nil
+	}
+	byBranch := make(map[string]*pullRequest)
+	for i := range prs {
+		pr := &prs[i]
+		// gh lists the newest first; a reopened topic keeps its latest PR
+		if _, ok := byBranch[pr.HeadRefName]; !ok {
+			byBranch[pr.HeadRefName] = &pr.pullRequest
+		}
+	}
+	return byBranch
+}
+
+// findTopics lists every agent's topic branches in cfg's repository,
+// comparing each with mainRef.
+func findTopics(cfg *ProjectConfig, mainRef string) ([]topicInfo, error) {
+	prs := listPullRequests(cfg.Root)
+
+	var topics []topicInfo
+	for i := range cfg.Agents {
+		agent := &cfg.Agents[i]
+		glob := cfg.TopicGlob(agent)
+		output, err := gitOutput(cfg.Root, "for-each-ref", "--format=%(refname)\t%(committerdate:unix)",
+			"refs/heads/"+glob, "refs/remotes/origin/"+glob)
+		if err != nil {
+			return nil, fmt.Errorf("could not list branches for %s: %v", agent.Name, err)
+		}
+		if output == "" {
+			continue
+		}
+
+		byBranch := make(map[string]*topicInfo)
+		var names []string
+		for _, line := range strings.Split(output, "\n") {
+			refname, date, _ := strings.Cut(line, "\t")
+			name, local := strings.CutPrefix(refname, "refs/heads/")
+			if !local {
+				name = strings.TrimPrefix(refname, "refs/remotes/origin/")
+			}
+			if _, ok := cfg.TopicOf(agent, name); !ok {
+				continue
+			}
+			topic := byBranch[name]
+			if topic == nil {
+				topic = &topicInfo{Agent: agent.Name, Branch: name}
+				byBranch[name] = topic
+				names = append(names, name)
+			}
+			if local {
+				topic.Local = true
+			} else {
+				topic.Remote = true
+			}
+			if unix, err := strconv.ParseInt(date, 10, 64); err == nil {
+				if t := time.Unix(unix, 0); t.After(topic.LastCommit) {
+					topic.LastCommit = t
+				}
+			}
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			topic := byBranch[name]
+			ref := "refs/heads/" + name
+			if !topic.Local {
+				ref = "refs/remotes/origin/" + name
+			}
+			// Pushed means origin has every commit of the local branch
+			topic.Pushed = topic.Remote
+			if topic.Local && topic.Remote {
+				count, err := gitOutput(cfg.Root, "rev-list", "--count", "refs/remotes/origin/"+name+".."+ref)
+				topic.Pushed = err == nil && count == "0"
+			}
+			if refExists(cfg.Root, mainRef) {
+				if ahead, behind, err := aheadBehind(cfg.Root, ref, mainRef); err == nil {
+					topic.Ahead, topic.Behind = ahead, behind
+				}
+			}
+			topic.PR = prs[name]
+			topics = append(topics, *topic)
+		}
+	}
+	return topics, nil
+}
+
+// runWorktreeTopicsImpl lists every agent's topic branches with how far
+// they are from the integration branch and their pull request, if any.
+func runWorktreeTopicsImpl(asJSON bool) error {
+	cfg := CurrentConfig()
+	if cfg.Root == "" {
+		return fmt.Errorf("not in a git repository")
+	}
+	mainRef := integrationRef(cfg.Root, integrationBranch(cfg, cfg.Root))
+	topics, err := findTopics(cfg, mainRef)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		if topics == nil {
+			topics = []topicInfo{}
+		}
+		data, err := json.MarshalIndent(topics, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	PrintHeader(fmt.Sprintf("Topic branches (compared with %s)", mainRef))
+	if len(topics) == 0 {
+		PrintInfo("No topic branches")
+		PrintInfo("Start one with 'agenter worktree make <topic>'")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "AGENT\tBRANCH\tAHEAD\tBEHIND\tLAST COMMIT\tPUSHED\tPR")
+	for _, topic := range topics {
+		pushed := "no"
+		switch {
+		case !topic.Local:
+			pushed = "origin only"
+		case topic.Pushed:
+			pushed = "yes"
+		case topic.Remote:
+			pushed = "behind local"
+		}
+		pr := "-"
+		if topic.PR != nil {
+			pr = fmt.Sprintf("#%d %s", topic.PR.Number, strings.ToLower(topic.PR.State))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\t%s\t%s\n", topic.Agent, topic.Branch,
+			topic.Ahead, topic.Behind, FormatAge(topic.LastCommit), pushed, pr)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestFindTopicsAcrossAgents(t *testing.T) {
+	repo := newTestRepo(t)
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	runTestGit(t, repo, "init", "-q", "--bare", origin)
+	runTestGit(t, repo, "remote", "add", "origin", origin)
+	runTestGit(t, repo, "push", "-q", "-u", "origin", "main")
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	cfg := CurrentConfig()
+	forge, axiom := cfg.WorktreePath("forge"), cfg.WorktreePath("axiom")
+	installFakeGH(t, `cat <<'EOF'
+[{"number":7,"state":"OPEN","url":"https://github.com/o/r/pull/7","headRefName":"forge-worktree-pushed"},
+ {"number":3,"state":"CLOSED","url":"https://github.com/o/r/pull/3","headRefName":"forge-worktree-pushed"},
+ {"number":5,"state":"MERGED","url":"https://github.com/o/r/pull/5","headRefName":"axiom-worktree-gone"}]
+EOF
+`)
+
+	commitTopic(t, forge, "forge-worktree-pushed", "pushed.txt")
+	commitTopic(t, forge, "forge-worktree-local", "local.txt")
+	commitTopic(t, axiom, "axiom-worktree-gone", "gone.txt")
+	runTestGit(t, forge, "push", "-q", "origin", "forge-worktree-pushed", "axiom-worktree-gone")
+	runTestGit(t, repo, "branch", "-D", "axiom-worktree-gone")
+	runTestGit(t, repo, "commit", "-q", "--allow-empty", "-m", "main moves on")
+
+	topics, err := findTopics(cfg, "main")
+	if err != nil {
+		t.Fatal(err)
+	}
+	byBranch := map[string]topicInfo{}
+	for _, topic := range topics {
+		byBranch[topic.Branch] = topic
+	}
+	if len(topics) != 3 {
+		t.Fatalf("topics = %+v", topics)
+	}
+
+	tests := []struct {
+		branch                string
+		agent                 string
+		local, remote, pushed bool
+		pr                    int
+	}{
+		{"forge-worktree-pushed", "forge", true, true, true, 7},
+		{"forge-worktree-local", "forge", true, false, false, 0},
+		{"axiom-worktree-gone", "axiom", false, true, true, 5},
+	}
+	for _, tt := range tests {
+		topic, ok := byBranch[tt.branch]
+		if !ok {
+			t.Errorf("%s missing", tt.branch)
+			continue
+		}
+		if topic.Agent != tt.agent || topic.Local != tt.local || topic.Remote != tt.remote || topic.Pushed != tt.pushed {
+			t.Errorf("%s = %+v", tt.branch, topic)
+		}
+		if topic.Ahead != 2 || topic.Behind != 1 {
+			t.Errorf("%s ahead %d behind %d, want 2 and 1", tt.branch, topic.Ahead, topic.Behind)
+		}
+		if topic.LastCommit.IsZero() {
+			t.Errorf("%s has no last commit time", tt.branch)
+		}
+		switch {
+		case tt.pr == 0 && topic.PR != nil:
+			t.Errorf("%s has PR %+v", tt.branch, topic.PR)
+		case tt.pr != 0 && (topic.PR == nil || topic.PR.Number != tt.pr):
+			t.Errorf("%s PR = %+v, want #%d", tt.branch, topic.PR, tt.pr)
+		}
+	}
+
+	// A local commit on top of the pushed branch isn't pushed yet
+	runTestGit(t, forge, "checkout", "-q", "forge-worktree-pushed")
+	runTestGit(t, forge, "commit", "-q", "--allow-empty", "-m", "more")
+	topics, _ = findTopics(cfg, "main")
+	for _, topic := range topics {
+		if topic.Branch == "forge-worktree-pushed" && topic.Pushed {
+			t.Error("branch with an unpushed commit reported as pushed")
+		}
+	}
+}
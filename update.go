@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// defaultMainBranch is the integration branch when the config doesn't
+// name one and origin/HEAD can't tell us.
+const defaultMainBranch = "main"
+
+// updateStrategies are the ways 'worktree next' can bring an agent's base
+// branch up to date with the integration branch.
+var updateStrategies = []string{"merge", "rebase", "reset"}
+
+// validateUpdateStrategy rejects strategies we don't know.
+func validateUpdateStrategy(strategy string) error {
+	for _, s := range updateStrategies {
+		if s == strategy {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown update strategy %q (must be one of %s)", strategy, strings.Join(updateStrategies, ", "))
+}
+
+// integrationBranch returns the branch agents' work is merged into: the
+// configured main_branch, else what origin/HEAD points at, else the
+// first of main, master, trunk and develop that exists.
+func integrationBranch(cfg *ProjectConfig, dir string) string {
+	if cfg.MainBranch != "" {
+		return cfg.MainBranch
+	}
+	if ref, err := gitOutput(dir, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimPrefix(ref, "origin/")
+	}
+	for _, branch := range []string{"main", "master", "trunk", "develop"} {
+		if refExists(dir, "origin/"+branch) || refExists(dir, "refs/heads/"+branch) {
+			return branch
+		}
+	}
+	return defaultMainBranch
+}
+
+// integrationRef returns the ref to compare against or update from for
+// branch: its copy on origin when there is one, otherwise the local
+// branch.
+func integrationRef(dir string, branch string) string {
+	if refExists(dir, "origin/"+branch) {
+		return "origin/" + branch
+	}
+	return branch
+}
+
+// conflictedFiles lists the files left unmerged in dir.
+func conflictedFiles(dir string) []string {
+	output, err := gitOutput(dir, "diff", "--name-only", "--diff-filter=U")
+	if err != nil || output == "" {
+		return nil
+	}
+	return strings.Split(output, "\n")
+}
+
+// updateResult is what bringing a base branch up to date did.
+type updateResult struct {
+	Ref string
+	// OldHead is where the branch was before.
+	OldHead string
+	// Ahead counts the branch's own commits that weren't on Ref, and
+	// Behind the commits on Ref it was missing.
+	Ahead  int
+	Behind int
+	// Conflicts lists the files git stopped on.
+	Conflicts []string
+}
+
+// updateFrom brings HEAD in dir up to date with ref using strategy,
+// without printing anything. On conflicts the merge or rebase is left in
+// progress and the files are in the result.
+func updateFrom(dir string, ref string, strategy string) (updateResult, error) {
+	result := updateResult{Ref: ref}
+	if err := validateUpdateStrategy(strategy); err != nil {
+		return result, err
+	}
+	if !refExists(dir, ref) {
+		return result, fmt.Errorf("integration branch %s not found; set main_branch in %s", ref, ProjectConfigFile)
+	}
+
+	var err error
+	if result.OldHead, err = gitOutput(dir, "rev-parse", "--short", "HEAD"); err != nil {
+		return result, err
+	}
+	if result.Ahead, result.Behind, err = aheadBehind(dir, "HEAD", ref); err != nil {
+		return result, err
+	}
+	if result.UpToDate(strategy) {
+		return result, nil
+	}
+
+	var args []string
+	switch strategy {
+	case "merge":
+		args = []string{"merge", "--no-edit", ref}
+	case "rebase":
+		args = []string{"rebase", ref}
+	case "reset":
+		args = []string{"reset", "--hard", ref}
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	output, err := cmd.CombinedOutput()
+	if err == nil {
+		return result, nil
+	}
+
+	result.Conflicts = conflictedFiles(dir)
+	if len(result.Conflicts) == 0 {
+		return result, fmt.Errorf("could not %s %s: %s", strategy, ref, strings.TrimSpace(string(output)))
+	}
+	return result, fmt.Errorf("%s stopped with %d conflicted files", strategy, len(result.Conflicts))
+}
+
+// UpToDate reports whether strategy had nothing to do. A reset still has
+// work while the branch has commits of its own.
+func (r updateResult) UpToDate(strategy string) bool {
+	return r.Behind == 0 && (r.Ahead == 0 || strategy != "reset")
+}
+
+// fetchIntegrationBranch fetches the integration branch from origin, if
+// there is one, and returns the ref to update from. A failed fetch only
+// warns, so agents can work offline.
+func fetchIntegrationBranch(dir string) string {
+	branch := integrationBranch(CurrentConfig(), dir)
+	if _, err := gitOutput(dir, "remote", "get-url", "origin"); err == nil {
+		PrintInfo("Fetching %s from origin...", branch)
+		if _, err := gitOutput(dir, "fetch", "origin", branch); err != nil {
+			PrintWarning("Could not fetch %s, using what we have: %v", branch, err)
+		}
+	}
+	return integrationRef(dir, branch)
+}
+
+// updateBaseBranch brings the branch checked out in dir up to date with
+// the integration branch using strategy. When git stops on conflicts the
+// files are listed along with how to finish or back out, and an error is
+// returned so nothing carries on from a half-updated branch.
+func updateBaseBranch(dir string, strategy string) error {
+	if err := validateUpdateStrategy(strategy); err != nil {
+		return err
+	}
+	ref := fetchIntegrationBranch(dir)
+
+	PrintInfo("Updating from %s (%s)...", ref, strategy)
+	result, err := updateFrom(dir, ref, strategy)
+	switch {
+	case err == nil && result.UpToDate(strategy):
+		PrintInfo("Already up to date with %s", ref)
+		return nil
+	case err == nil:
+		if strategy == "reset" && result.Ahead > 0 {
+			PrintWarning("Dropped %d commits not on %s; undo with 'git reset --hard %s'", result.Ahead, ref, result.OldHead)
+		}
+		PrintSuccess("Updated from %s", ref)
+		return nil
+	case len(result.Conflicts) == 0:
+		return err
+	}
+
+	PrintError("Conflicts updating from %s:", ref)
+	for _, file := range result.Conflicts {
+		fmt.Printf("  %s\n", file)
+	}
+	fmt.Println()
+	switch strategy {
+	case "merge":
+		PrintInfo("Fix the files, then 'git add' them and 'git commit' to finish the merge")
+		PrintInfo("Or 'git merge --abort' to put the branch back as it was")
+	case "rebase":
+		PrintInfo("Fix the files, then 'git add' them and 'git rebase --continue'")
+		PrintInfo("Or 'git rebase --abort' to put the branch back as it was")
+	}
+	return err
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestIntegrationBranch(t *testing.T) {
+	repo := newTestRepo(t)
+	cfg := &ProjectConfig{}
+
+	if got := integrationBranch(cfg, repo); got != "main" {
+		t.Errorf("local main: got %q", got)
+	}
+
+	runTestGit(t, repo, "branch", "-m", "main", "master")
+	if got := integrationBranch(cfg, repo); got != "master" {
+		t.Errorf("local master: got %q", got)
+	}
+
+	// origin/HEAD wins over guessing
+	head := strings.TrimSpace(runTestGit(t, repo, "rev-parse", "HEAD"))
+	runTestGit(t, repo, "update-ref", "refs/remotes/origin/develop", head)
+	runTestGit(t, repo, "symbolic-ref", "refs/remotes/origin/HEAD", "refs/remotes/origin/develop")
+	if got := integrationBranch(cfg, repo); got != "develop" {
+		t.Errorf("origin/HEAD: got %q", got)
+	}
+	if got := integrationRef(repo, "develop"); got != "origin/develop" {
+		t.Errorf("integrationRef = %q", got)
+	}
+
+	cfg.MainBranch = "trunk"
+	if got := integrationBranch(cfg, repo); got != "trunk" {
+		t.Errorf("configured: got %q", got)
+	}
+
+	if _, err := ParseProjectConfig([]byte("update_strategy: squash\nagents:\n  - name: forge\n")); err == nil {
+		t.Error("expected unknown update strategy to be rejected")
+	}
+}
+
+// setupNextRepo makes a project on branch trunk with an origin, moves
+// origin's trunk on after setup, and leaves us in forge's worktree on
+// its base branch with a commit of its own touching file.
+func setupNextRepo(t *testing.T, file string) (repo string, forge string) {
+	t.Helper()
+	repo = newTestRepo(t)
+	runTestGit(t, repo, "branch", "-m", "main", "trunk")
+	origin := filepath.Join(t.TempDir(), "origin.git")
+	runTestGit(t, repo, "init", "-q", "--bare", origin)
+	runTestGit(t, repo, "remote", "add", "origin", origin)
+	runTestGit(t, repo, "push", "-q", "-u", "origin", "trunk")
+	runTestGit(t, repo, "remote", "set-head", "origin", "trunk")
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	forge = CurrentConfig().WorktreePath("forge")
+
+	os.WriteFile(filepath.Join(repo, "README.md"), []byte("upstream\n"), 0644)
+	runTestGit(t, repo, "commit", "-q", "-am", "upstream change")
+	runTestGit(t, repo, "push", "-q", "origin", "trunk")
+
+	os.WriteFile(filepath.Join(forge, file), []byte("forge\n"), 0644)
+	runTestGit(t, forge, "add", file)
+	runTestGit(t, forge, "commit", "-q", "-m", "forge change")
+	os.Chdir(forge)
+	return repo, forge
+}
+
+func TestWorktreeNextStrategies(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	tests := []struct {
+		strategy string
+		// forgeKept is whether forge's own commit survives
+		forgeKept bool
+		// merged is whether the update made a merge commit
+		merged bool
+	}{
+		{"merge", true, true},
+		{"rebase", true, false},
+		{"reset", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			_, forge := setupNextRepo(t, "forge.txt")
+			if err := runWorktreeNextImpl("", nextOptions{Strategy: tt.strategy}); err != nil {
+				t.Fatal(err)
+			}
+
+			if data, _ := os.ReadFile(filepath.Join(forge, "README.md")); string(data) != "upstream\n" {
+				t.Errorf("README.md = %q, want origin's change", data)
+			}
+			if _, err := os.Stat(filepath.Join(forge, "forge.txt")); (err == nil) != tt.forgeKept {
+				t.Errorf("forge.txt kept = %v, want %v", err == nil, tt.forgeKept)
+			}
+			parents := strings.Fields(runTestGit(t, forge, "log", "-1", "--format=%p"))
+			if merged := len(parents) == 2; merged != tt.merged {
+				t.Errorf("merge commit = %v, want %v", merged, tt.merged)
+			}
+			if branch := strings.TrimSpace(runTestGit(t, forge, "rev-parse", "--abbrev-ref", "HEAD")); branch != "forge-worktree" {
+				t.Errorf("on %s", branch)
+			}
+		})
+	}
+}
+
+func TestWorktreeNextStopsOnConflicts(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	for _, strategy := range []string{"merge", "rebase"} {
+		t.Run(strategy, func(t *testing.T) {
+			_, forge := setupNextRepo(t, "README.md")
+			runTestGit(t, forge, "checkout", "-q", "-b", "forge-worktree-fix")
+
+			err := runWorktreeNextImpl("next-topic", nextOptions{Strategy: strategy})
+			if err == nil || !strings.Contains(err.Error(), "1 conflicted files") {
+				t.Fatalf("expected a conflict error, got %v", err)
+			}
+			if got := conflictedFiles(forge); len(got) != 1 || got[0] != "README.md" {
+				t.Errorf("conflicted files = %v", got)
+			}
+			// The next topic isn't started on a half-updated branch
+			if refExists(forge, "refs/heads/forge-worktree-next-topic") {
+				t.Error("topic branch created despite conflicts")
+			}
+		})
+	}
+}
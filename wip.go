@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// wipCommitMessage marks commits made by 'worktree next --wip', so
+// 'worktree resume' knows it can undo them.
+const wipCommitMessage = "WIP: saved by agenter worktree next"
+
+// nextOptions are the flags of 'worktree next'.
+type nextOptions struct {
+	// Issue fills in {issue} in the new topic's branch name.
+	Issue string
+	// Strategy overrides update_strategy when set.
+	Strategy string
+	// Stash saves uncommitted changes in a stash named for the branch.
+	Stash bool
+	// WIP commits uncommitted changes to the branch.
+	WIP bool
+}
+
+// stashMessage names the stash holding branch's uncommitted work.
+func stashMessage(branch string) string {
+	return "agenter: saved from " + branch
+}
+
+// findStash returns the stash ref (e.g. stash@{1}) holding branch's
+// work, or "". Stashes are shared by all worktrees, so the name is what
+// tells them apart.
+func findStash(dir string, branch string) string {
+	output, err := gitOutput(dir, "stash", "list", "--format=%gd%x09%s")
+	if err != nil || output == "" {
+		return ""
+	}
+	for _, line := range strings.Split(output, "\n") {
+		ref, subject, _ := strings.Cut(line, "\t")
+		if strings.HasSuffix(subject, ": "+stashMessage(branch)) {
+			return ref
+		}
+	}
+	return ""
+}
+
+// saveWork puts dir's uncommitted changes on branch away, in a stash or
+// as a WIP commit.
+func saveWork(dir string, branch string, opts nextOptions) error {
+	if opts.WIP {
+		if _, err := gitOutput(dir, "add", "--all"); err != nil {
+			return fmt.Errorf("could not stage changes: %v", err)
+		}
+		// Skip hooks: the work is unfinished by definition
+		if _, err := gitOutput(dir, "commit", "--quiet", "--no-verify", "-m", wipCommitMessage); err != nil {
+			return fmt.Errorf("could not commit WIP: %v", err)
+		}
+		PrintSuccess("Committed work in progress to %s", branch)
+		return nil
+	}
+
+	if findStash(dir, branch) != "" {
+		return fmt.Errorf("%s already has stashed work; run 'agenter worktree resume' on it first", branch)
+	}
+	if _, err := gitOutput(dir, "stash", "push", "--include-untracked", "-m", stashMessage(branch)); err != nil {
+		return fmt.Errorf("could not stash changes: %v", err)
+	}
+	PrintSuccess("Stashed work in progress from %s", branch)
+	return nil
+}
+
+// restoreWork undoes saveWork for branch, which must be checked out in
+// dir. It reports whether there was anything to restore.
+func restoreWork(dir string, branch string) (bool, error) {
+	restored := false
+	if subject, err := gitOutput(dir, "log", "-1", "--format=%s"); err == nil && subject == wipCommitMessage {
+		if _, err := gitOutput(dir, "reset", "--quiet", "HEAD~1"); err != nil {
+			return false, fmt.Errorf("could not undo WIP commit: %v", err)
+		}
+		PrintSuccess("Undid WIP commit; its changes are uncommitted again")
+		restored = true
+	}
+
+	if ref := findStash(dir, branch); ref != "" {
+		if _, err := gitOutput(dir, "stash", "pop", "--quiet", ref); err != nil {
+			// A conflicting pop keeps the stash, so nothing is lost
+			return restored, fmt.Errorf("could not restore stashed work (it's still in %s): %v", ref, err)
+		}
+		PrintSuccess("Restored stashed work")
+		restored = true
+	}
+	return restored, nil
+}
+
+// findTopicBranch returns agent's existing branch for topic, which may
+// also be given as the full branch name.
+func findTopicBranch(cfg *ProjectConfig, agent *AgentConfig, topic string) (string, bool) {
+	for _, branch := range topicBranches(cfg, agent) {
+		if t, _ := cfg.TopicOf(agent, branch); t == topic || branch == topic {
+			return branch, true
+		}
+	}
+	return "", false
+}
+
+// resumeTopic checks out branch in dir and restores any work saved
+// when leaving it.
+func resumeTopic(dir string, branch string) error {
+	if _, err := gitOutput(dir, "checkout", "--quiet", branch); err != nil {
+		return fmt.Errorf("could not switch to %s: %v", branch, err)
+	}
+	PrintSuccess("Switched to topic branch: %s", branch)
+	_, err := restoreWork(dir, branch)
+	return err
+}
+
+// runWorktreeResumeImpl returns to an existing topic branch and
+// restores the work 'worktree next --stash' or '--wip' saved from it.
+func runWorktreeResumeImpl(topic string) error {
+	agent, cwd, err := currentAgent()
+	if err != nil {
+		return err
+	}
+	cfg := CurrentConfig()
+	branch, ok := findTopicBranch(cfg, agent, topic)
+	if !ok {
+		return fmt.Errorf("%s has no topic %s; see 'agenter worktree topics'", agent.Name, topic)
+	}
+
+	currentBranch, err := getCurrentBranch()
+	if err != nil {
+		return fmt.Errorf("could not get current branch: %v", err)
+	}
+	if currentBranch != branch {
+		if status, err := gitOutput(cwd, "status", "--porcelain"); err != nil {
+			return fmt.Errorf("could not check git status: %v", err)
+		} else if status != "" {
+			PrintWarning("You have uncommitted changes on %s", currentBranch)
+			PrintInfo("Use 'agenter worktree next --stash' or '--wip' to put them away first")
+			return fmt.Errorf("unsaved changes")
+		}
+		return resumeTopic(cwd, branch)
+	}
+
+	restored, err := restoreWork(cwd, branch)
+	if err == nil && !restored {
+		PrintInfo("Already on %s with no saved work to restore", branch)
+	}
+	return err
+}
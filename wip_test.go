@@ -0,0 +1,108 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWorktreeNextSavesAndResumesWork(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	tests := []struct {
+		name string
+		opts nextOptions
+		// viaNext returns with 'worktree next fix' instead of resume
+		viaNext bool
+	}{
+		{"stash", nextOptions{Stash: true}, false},
+		{"wip", nextOptions{WIP: true}, false},
+		{"stash via next", nextOptions{Stash: true}, true},
+		{"wip via next", nextOptions{WIP: true}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newTestRepo(t)
+			if err := runSetupImpl(repo); err != nil {
+				t.Fatal(err)
+			}
+			forge := CurrentConfig().WorktreePath("forge")
+			os.Chdir(forge)
+			if err := runWorktreeMakeImpl("fix", ""); err != nil {
+				t.Fatal(err)
+			}
+			os.WriteFile(filepath.Join(forge, "done.txt"), []byte("done\n"), 0644)
+			runTestGit(t, forge, "add", "done.txt")
+			runTestGit(t, forge, "commit", "-q", "-m", "add done.txt")
+
+			// Half-finished work: an edit and a new file
+			os.WriteFile(filepath.Join(forge, "README.md"), []byte("edited\n"), 0644)
+			os.WriteFile(filepath.Join(forge, "new.txt"), []byte("new\n"), 0644)
+
+			if err := runWorktreeNextImpl("", nextOptions{}); err == nil || !strings.Contains(err.Error(), "unsaved changes") {
+				t.Fatalf("expected next to refuse, got %v", err)
+			}
+			if err := runWorktreeNextImpl("", tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if branch := strings.TrimSpace(runTestGit(t, forge, "rev-parse", "--abbrev-ref", "HEAD")); branch != "forge-worktree" {
+				t.Fatalf("on %s after next", branch)
+			}
+			if status := runTestGit(t, forge, "status", "--porcelain"); status != "" {
+				t.Fatalf("base branch not clean:\n%s", status)
+			}
+
+			var err error
+			if tt.viaNext {
+				err = runWorktreeNextImpl("fix", nextOptions{})
+			} else {
+				err = runWorktreeResumeImpl("fix")
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if branch := strings.TrimSpace(runTestGit(t, forge, "rev-parse", "--abbrev-ref", "HEAD")); branch != "forge-worktree-fix" {
+				t.Errorf("on %s after resume", branch)
+			}
+			if data, _ := os.ReadFile(filepath.Join(forge, "README.md")); string(data) != "edited\n" {
+				t.Errorf("README.md = %q, want the edit back", data)
+			}
+			if _, err := os.Stat(filepath.Join(forge, "new.txt")); err != nil {
+				t.Error("new.txt not restored")
+			}
+			if subject := strings.TrimSpace(runTestGit(t, forge, "log", "-1", "--format=%s")); subject != "add done.txt" {
+				t.Errorf("HEAD is %q, want the WIP commit undone", subject)
+			}
+			if ref := findStash(forge, "forge-worktree-fix"); ref != "" {
+				t.Errorf("stash %s left behind", ref)
+			}
+		})
+	}
+}
+
+func TestWorktreeNextWontSaveBaseBranchWork(t *testing.T) {
+	originalDir, _ := os.Getwd()
+	defer os.Chdir(originalDir)
+
+	repo := newTestRepo(t)
+	if err := runSetupImpl(repo); err != nil {
+		t.Fatal(err)
+	}
+	forge := CurrentConfig().WorktreePath("forge")
+	os.Chdir(forge)
+	os.WriteFile(filepath.Join(forge, "README.md"), []byte("edited\n"), 0644)
+
+	err := runWorktreeNextImpl("", nextOptions{Stash: true})
+	if err == nil || !strings.Contains(err.Error(), "only save work on a topic branch") {
+		t.Errorf("expected next to refuse, got %v", err)
+	}
+	if err := runWorktreeNextImpl("", nextOptions{Stash: true, WIP: true}); err == nil {
+		t.Error("expected --stash with --wip to be rejected")
+	}
+	if err := runWorktreeResumeImpl("nope"); err == nil || !strings.Contains(err.Error(), "has no topic nope") {
+		t.Errorf("expected unknown topic error, got %v", err)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 )
 
@@ -21,23 +20,48 @@ func getCurrentBranch() (string, error) {
 
 // getWorktreeBranch returns the worktree branch name for the current directory
 func getWorktreeBranch() (string, error) {
-	cwd, err := os.Getwd()
+	agent, _, err := currentAgent()
 	if err != nil {
 		return "", err
 	}
+	return agent.Branch, nil
+}
 
-	dir := filepath.Base(cwd)
-	for _, agent := range []string{"forge", "axiom", "jarvis"} {
-		if strings.HasSuffix(dir, fmt.Sprintf("-%s", agent)) {
-			return fmt.Sprintf("%s-worktree", agent), nil
-		}
+// currentAgent returns the agent whose worktree we're in, along with the
+// current directory.
+func currentAgent() (*AgentConfig, string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, "", err
 	}
 
-	return "", fmt.Errorf("not in an agent worktree directory")
+	agent := CurrentConfig().AgentForDir(cwd)
+	if agent == nil {
+		return nil, "", fmt.Errorf("not in an agent worktree directory")
+	}
+	return agent, cwd, nil
 }
 
-// runWorktreeMakeImpl creates a new topic branch
-func runWorktreeMakeImpl(topic string) error {
+// resolveTopic returns topic if it's valid, or its slug if the user
+// accepts that instead.
+func resolveTopic(topic string) (string, error) {
+	err := validateTopic(topic)
+	if err == nil {
+		return topic, nil
+	}
+	slug := slugifyTopic(topic)
+	if slug == "" {
+		return "", err
+	}
+	if !Confirm(fmt.Sprintf("Invalid %v. Use %q instead?", err, slug)) {
+		return "", fmt.Errorf("%v; try %q", err, slug)
+	}
+	return slug, nil
+}
+
+// runWorktreeMakeImpl creates a new topic branch, named by the
+// project's branch template. Invalid topics can be slugified.
+func runWorktreeMakeImpl(topic string, issue string) error {
 	// Get the worktree branch
 	worktreeBranch, err := getWorktreeBranch()
 	if err != nil {
@@ -57,8 +81,17 @@ func runWorktreeMakeImpl(topic string) error {
 		return fmt.Errorf("already on a topic branch")
 	}
 
+	agent, cwd, _ := currentAgent()
+	topic, err = resolveTopic(topic)
+	if err != nil {
+		return err
+	}
+	branchName, err := CurrentConfig().TopicBranch(agent, topic, issue)
+	if err != nil {
+		return err
+	}
+
 	// Create and checkout new topic branch
-	branchName := fmt.Sprintf("%s-%s", worktreeBranch, topic)
 	cmd := exec.Command("git", "checkout", "-b", branchName)
 	if output, err := cmd.CombinedOutput(); err != nil {
 		return fmt.Errorf("could not create topic branch: %s", string(output))
@@ -66,11 +99,13 @@ func runWorktreeMakeImpl(topic string) error {
 
 	PrintSuccess("Created topic branch: %s", branchName)
 	PrintInfo("Now working on topic: %s", topic)
-	return nil
+
+	return runHooks("post_topic_make", agent, cwd, "AGENTER_TOPIC="+topic, "AGENTER_BRANCH="+branchName)
 }
 
-// runWorktreePushImpl pushes the current topic branch
-func runWorktreePushImpl() error {
+// runWorktreePushImpl pushes the current topic branch and links to, or
+// with opts.PR opens, its pull request
+func runWorktreePushImpl(opts pushOptions) error {
 	// Get current branch
 	currentBranch, err := getCurrentBranch()
 	if err != nil {
@@ -83,6 +118,25 @@ func runWorktreePushImpl() error {
 		return fmt.Errorf("no topic to push. Create a topic branch first with 'agenter worktree make <topic>'")
 	}
 
+	// Outside an agent worktree the branch is simply pushed, with no
+	// branch template, hooks or pull request
+	agent, cwd, err := currentAgent()
+	if err != nil {
+		if opts.PR {
+			return fmt.Errorf("--pr only works in an agent worktree: %v", err)
+		}
+		if cwd, err = os.Getwd(); err != nil {
+			return err
+		}
+	} else if _, ok := CurrentConfig().TopicOf(agent, currentBranch); !ok {
+		PrintWarning("%s doesn't match the branch template %s", currentBranch, CurrentConfig().branchTemplate())
+	}
+
+	// pre_push can run the tests and stop a broken push
+	if err := runHooks("pre_push", agent, cwd, "AGENTER_BRANCH="+currentBranch); err != nil {
+		return err
+	}
+
 	PrintInfo("Pushing topic branch: %s", currentBranch)
 
 	// Push the branch
@@ -92,36 +146,50 @@ func runWorktreePushImpl() error {
 		return fmt.Errorf("could not push: %s", string(output))
 	}
 
-	// Get the remote URL
-	cmd = exec.Command("git", "remote", "get-url", "origin")
-	remoteOutput, err := cmd.Output()
-	if err != nil {
-		PrintSuccess("Branch pushed successfully")
-		return nil
-	}
+	PrintSuccess("Branch pushed successfully")
 
-	// Parse GitHub URL and generate PR link
-	remote := strings.TrimSpace(string(remoteOutput))
-	if strings.Contains(remote, "github.com") {
-		// Convert git@github.com:owner/repo.git to https://github.com/owner/repo
-		prURL := remote
-		prURL = strings.Replace(prURL, "git@github.com:", "https://github.com/", 1)
-		prURL = strings.TrimSuffix(prURL, ".git")
-		prURL = fmt.Sprintf("%s/pull/new/%s", prURL, currentBranch)
+	if opts.PR {
+		prURL, err := openPullRequest(CurrentConfig(), agent, cwd, currentBranch, opts)
+		if err != nil {
+			return err
+		}
+		fmt.Println(prURL)
+		return runHooks("post_push", agent, cwd, "AGENTER_BRANCH="+currentBranch, "AGENTER_PR_URL="+prURL)
+	}
 
-		PrintSuccess("Branch pushed successfully")
+	// Link to opening a pull request on the remote's host
+	var prURL string
+	if remote, err := gitOutput(cwd, "remote", "get-url", "origin"); err == nil {
+		cfg := CurrentConfig()
+		prURL = cfg.PullRequestURL(remote, currentBranch, integrationBranch(cfg, cwd))
+	}
+	if prURL != "" {
 		fmt.Println()
 		PrintBold("Create PR at:")
 		fmt.Println(prURL)
-	} else {
-		PrintSuccess("Branch pushed successfully")
 	}
 
-	return nil
+	return runHooks("post_push", agent, cwd, "AGENTER_BRANCH="+currentBranch, "AGENTER_PR_URL="+prURL)
 }
 
-// runWorktreeNextImpl returns to base branch and optionally creates new topic
-func runWorktreeNextImpl(newTopic string) error {
+// runWorktreeNextImpl returns to base branch, updates it from the
+// integration branch using opts.Strategy (the configured one if empty)
+// and optionally starts new topic, or resumes it if it exists
+func runWorktreeNextImpl(newTopic string, opts nextOptions) error {
+	if opts.Stash && opts.WIP {
+		return fmt.Errorf("use either --stash or --wip, not both")
+	}
+	strategy := opts.Strategy
+	if strategy == "" {
+		strategy = CurrentConfig().UpdateStrategy
+	}
+	if strategy == "" {
+		strategy = "merge"
+	}
+	if err := validateUpdateStrategy(strategy); err != nil {
+		return err
+	}
+
 	// Get the worktree branch
 	worktreeBranch, err := getWorktreeBranch()
 	if err != nil {
@@ -134,9 +202,30 @@ func runWorktreeNextImpl(newTopic string) error {
 		return fmt.Errorf("could not get current branch: %v", err)
 	}
 
-	// If on worktree branch and new topic provided, just create it
-	if currentBranch == worktreeBranch && newTopic != "" {
-		return runWorktreeMakeImpl(newTopic)
+	// Check the new topic before leaving the current one
+	agent, cwd, _ := currentAgent()
+	existingTopic, resume := "", false
+	if newTopic != "" {
+		existingTopic, resume = findTopicBranch(CurrentConfig(), agent, newTopic)
+	}
+	if newTopic != "" && !resume {
+		if newTopic, err = resolveTopic(newTopic); err != nil {
+			return err
+		}
+		if _, err := CurrentConfig().TopicBranch(agent, newTopic, opts.Issue); err != nil {
+			return err
+		}
+	}
+	startTopic := func() error {
+		if resume {
+			return resumeTopic(cwd, existingTopic)
+		}
+		return runWorktreeMakeImpl(newTopic, opts.Issue)
+	}
+
+	// If on worktree branch and new topic provided, just start it
+	if currentBranch == worktreeBranch && newTopic != "" && !resume {
+		return startTopic()
 	}
 
 	// Ensure changes are committed or stashed
@@ -147,9 +236,17 @@ func runWorktreeNextImpl(newTopic string) error {
 	}
 
 	if len(output) > 0 {
-		PrintWarning("You have uncommitted changes")
-		PrintInfo("Please commit or stash changes before switching branches")
-		return fmt.Errorf("unsaved changes")
+		if !opts.Stash && !opts.WIP {
+			PrintWarning("You have uncommitted changes")
+			PrintInfo("Commit them, or use --stash or --wip to save them for 'agenter worktree resume'")
+			return fmt.Errorf("unsaved changes")
+		}
+		if currentBranch == worktreeBranch {
+			return fmt.Errorf("--stash and --wip only save work on a topic branch; commit or discard changes to %s", worktreeBranch)
+		}
+		if err := saveWork(cwd, currentBranch, opts); err != nil {
+			return err
+		}
 	}
 
 	// Return to worktree branch
@@ -160,17 +257,18 @@ func runWorktreeNextImpl(newTopic string) error {
 
 	PrintSuccess("Returned to base branch: %s", worktreeBranch)
 
-	// Update from main
-	PrintInfo("Updating from main...")
-	cmd = exec.Command("git", "pull", "origin", "main")
-	if output, err := cmd.CombinedOutput(); err != nil {
-		PrintWarning("Could not pull from main: %s", string(output))
+	if err := updateBaseBranch(cwd, strategy); err != nil {
+		return err
 	}
 
-	// Create new topic if provided
+	if err := runHooks("post_next", agent, cwd, "AGENTER_BRANCH="+worktreeBranch, "AGENTER_PREVIOUS_BRANCH="+currentBranch); err != nil {
+		return err
+	}
+
+	// Start or resume the new topic if provided
 	if newTopic != "" {
 		fmt.Println()
-		return runWorktreeMakeImpl(newTopic)
+		return startTopic()
 	}
 
 	PrintInfo("Ready for next topic. Use 'agenter worktree make <topic>' to start")
@@ -189,21 +287,21 @@ func runWorktreeListImpl() error {
 
 	scanner := bufio.NewScanner(strings.NewReader(string(output)))
 	foundAgent := false
+	cfg := CurrentConfig()
 
 	for scanner.Scan() {
-		line := scanner.Text()
-		for _, agent := range []string{"forge", "axiom", "jarvis"} {
-			if strings.Contains(line, fmt.Sprintf("-%s", agent)) {
-				parts := strings.Fields(line)
-				if len(parts) >= 3 {
-					path := parts[0]
-					branch := strings.Trim(parts[2], "[]")
-					fmt.Printf("  %s: %s [%s]\n", PrintAgent(agent), FormatPath(path), branch)
-					foundAgent = true
-				}
-				break
-			}
+		parts := strings.Fields(scanner.Text())
+		if len(parts) < 3 {
+			continue
+		}
+		agent := cfg.AgentForDir(parts[0])
+		if agent == nil {
+			continue
 		}
+		path := parts[0]
+		branch := strings.Trim(parts[2], "[]")
+		fmt.Printf("  %s: %s [%s]\n", PrintAgent(agent.Name), FormatPath(path), branch)
+		foundAgent = true
 	}
 
 	if !foundAgent {